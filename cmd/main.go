@@ -4,33 +4,140 @@ import (
 	"context"
 	"flag"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/maksim-paskal/k8s-find-obj/internal"
+	"github.com/pkg/errors"
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	application := internal.NewApplication()
 
-	flag.StringVar(&application.Kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file to use for CLI requests.")
-	flag.StringVar(&application.WhereToSearch, "where", "*", "Where to run the application. Options: local, cluster")
-	flag.StringVar(&application.WhatToSearch, "find", "", "What to search for.")
-	flag.StringVar(&application.Namespace, "namespace", "", "Namespace to use for the search.")
-	flag.StringVar(&application.Except, "except", "", "What to exclude from the search.")
+	flag.StringVar(&application.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use for CLI requests, $KUBECONFIG or ~/.kube/config by default.")
+	flag.StringVar(&application.KubeContext, "context", "", "Name of kubeconfig context to use, current context by default.")
+	flag.BoolVar(&application.InCluster, "in-cluster", false, "Use service account of pod instead of kubeconfig, it is used by default when there is no kubeconfig.")
+	flag.Float64Var(&application.QPS, "qps", application.QPS, "Maximum queries per second to API server. Too high values can pressure API server.")
+	flag.IntVar(&application.Burst, "burst", application.Burst, "Maximum burst of queries to API server. Too high values can pressure API server.")
+	flag.StringVar(&application.FakeFromDir, "fake-from-dir", "", "Search manifests from this directory loaded into fake cluster instead of real one.")
+	flag.StringVar(&application.WhereToSearch, "where", "*", "Where to search, comma separated kinds like pods,configmaps or * for all kinds. Add local to search manifests from -path instead of cluster, cluster is default.")
+	flag.StringVar(&application.Path, "path", "", "File or directory with manifests for -where=local, stdin when empty or -.")
+	flag.Func("find", "What to search for, regular expression, case insensitive unless -case-sensitive. Can be repeated to match any of patterns.", func(pattern string) error {
+		application.WhatToSearch = append(application.WhatToSearch, pattern)
+
+		return nil
+	})
+	flag.StringVar(&application.Namespace, "namespace", "", "Comma separated namespaces to use for the search, all namespaces when empty.")
+	flag.BoolVar(&application.AllNamespaces, "all-namespaces", false, "Search in all namespaces, can not be used with -namespace.")
+	flag.BoolVar(&application.AllNamespaces, "A", false, "Shorthand for -all-namespaces.")
+	flag.StringVar(&application.GVR, "gvr", "", "Also search custom resource in group/version/resource form, for example cert-manager.io/v1/certificates.")
+	flag.StringVar(&application.Selector, "selector", "", "Label selector to filter objects on server, for example app=foo.")
+	flag.StringVar(&application.FieldSelector, "field-selector", "", "Field selector to filter objects on server, for example status.phase=Running. Kinds that do not support it are skipped.")
+	flag.StringVar(&application.Node, "node", "", "Search only pods scheduled on this node.")
+	flag.StringVar(&application.Except, "except", "", "What to exclude from the search, regular expression matched against -except-format, case insensitive unless -case-sensitive.")
+	flag.StringVar(&application.ExceptFormat, "except-format", application.ExceptFormat, "Text matched by -except: namespace/name or kind/namespace/name, kind is plural like Pods.")
+	flag.StringVar(&application.ExceptKind, "except-kind", "", "Comma separated kinds to skip, for example configmaps,secrets.")
+	flag.BoolVar(&application.Strict, "strict", false, "Fail on unknown kinds in -where and -except-kind and on forbidden list requests instead of warning.")
+	flag.IntVar(&application.MaxMatches, "max-matches", 0, "Maximum number of matches printed for every object, zero means no limit.")
+	flag.IntVar(&application.MaxObjectSize, "max-object-size", 0, "Skip objects bigger than this number of bytes with warning, 0 is unlimited.")
+	flag.BoolVar(&application.Invert, "invert", false, "Report objects that do not match, like grep -v.")
+	flag.BoolVar(&application.CaseSensitive, "case-sensitive", false, "Match -find and -except patterns case sensitive.")
+	flag.BoolVar(&application.Word, "word", false, "Match patterns only as whole words like grep -w.")
+	flag.IntVar(&application.ShowTails, "tails", application.ShowTails, "Number of bytes of text shown around every match, zero shows only matched text.")
+	flag.IntVar(&application.ContextLines, "context-lines", 0, "Show whole lines of match and this number of lines around it like grep -C instead of -tails.")
+	flag.DurationVar(&application.Timeout, "timeout", 0, "Timeout for the whole search. Zero means no timeout.")
+	flag.IntVar(&application.Retries, "retries", application.Retries, "Number of retries of list requests failed with transient errors, like throttling or server errors.")
+	flag.Int64Var(&application.MaxTotalRetries, "max-total-retries", 0, "Maximum number of list retries of the whole search, search fails when they are used. Zero means no limit.")
+	flag.DurationVar(&application.ListTimeout, "list-timeout", 0, "Timeout for each list request, kinds that time out are skipped. Zero means no timeout.")
+	flag.StringVar(&application.Color, "color", application.Color, "Highlight matched text in text output. Options: auto, always, never")
+	flag.BoolVar(&application.Squeeze, "squeeze", false, "Collapse repeated whitespace in results.")
+	flag.StringVar(&application.RedactPattern, "redact-pattern", "", "Replace text matching this regexp with *** in every result.")
+	flag.Float64Var(&application.Sample, "sample", application.Sample, "Fraction of objects of each kind to search, in range (0, 1]. Results are approximate when less than 1.")
+	flag.Uint64Var(&application.SampleSeed, "sample-seed", 0, "Seed for -sample to get reproducible results, random by default.")
+	flag.StringVar(&application.ClusterLabel, "cluster-label", "", "Label results with cluster identity, Go template with .Context and .Host fields, for example {{.Context}}.")
+	flag.StringVar(&application.Output, "output", application.Output, "Output format. Options: text, json, ndjson, yaml, csv, template, compact, histogram, summary-only, name, objects (matching objects as yaml)")
+	flag.StringVar(&application.OutputFile, "output-file", "", "Write matches to this file instead of stdout, logs are not written there.")
+	flag.StringVar(&application.Delimiter, "delimiter", application.Delimiter, "Field delimiter of csv output, \\t for tab separated values.")
+	flag.StringVar(&application.Template, "template", "", "Go template executed for every match with template output.")
+	flag.StringVar(&application.TemplateFile, "template-file", "", "File with Go template executed for every match with template output.")
+	flag.BoolVar(&application.ShortKind, "short-kind", false, "Print kind without api version in text output.")
+	flag.BoolVar(&application.Watch, "watch", false, "After search keep watching selected kinds and print matches of added and modified objects until interrupted.")
+	flag.DurationVar(&application.WatchDebounce, "watch-debounce", 0, "Search object changed in watch mode once per this duration with its latest state, zero searches every change.")
+	flag.IntVar(&application.Workers, "workers", application.Workers, "Number of objects searched concurrently.")
+	flag.BoolVar(&application.Buffered, "buffered", false, "Fetch all objects before search to print matches in stable order, by default matches are printed as soon as objects are fetched.")
+	flag.BoolVar(&application.Sort, "sort", false, "Sort results by kind, namespace and name, implies -buffered.")
+	flag.BoolVar(&application.Rank, "rank", false, "Sort results by number of matches in object, best first, implies -buffered.")
+	flag.DurationVar(&application.Since, "since", 0, "Search only objects created within this duration, events last seen within it.")
+	flag.StringVar(&application.SinceResourceVersion, "since-resource-version", "", "Search only objects modified after this resourceVersion, latest one is printed at the end for next run. Versions are compared as numbers which holds for etcd based clusters but is not guaranteed by Kubernetes.")
+	flag.BoolVar(&application.SkipTerminating, "skip-terminating", false, "Skip objects that are being deleted.")
+	flag.BoolVar(&application.OnlyTerminating, "only-terminating", false, "Search only objects that are being deleted.")
+	flag.BoolVar(&application.Progress, "progress", false, "Log number of fetched objects of long lists every few seconds, only when stderr is terminal.")
+	flag.Int64Var(&application.PageSize, "page-size", application.PageSize, "Number of objects fetched by one list request, zero fetches all objects at once.")
+	flag.Int64Var(&application.LimitPerKind, "limit-per-kind", 0, "Maximum number of objects of each kind to search, zero means no limit.")
+	flag.BoolVar(&application.Count, "count", false, "Print only number of matching objects and matches of every kind instead of matches.")
+	flag.BoolVar(&application.CountObjects, "count-objects", false, "Only count objects that would be searched and exit.")
+	flag.BoolVar(&application.DryRun, "dry-run", false, "Print kinds, namespaces and patterns that would be searched and exit without calling API.")
+	flag.IntVar(&application.Port, "port", 0, "Find services, pods and ingresses exposing or targeting this port number.")
+	flag.StringVar(&application.References, "references", "", "Find pods and workloads which use configmap/name or secret/name in volumes and environment.")
+	flag.BoolVar(&application.FindVolume, "find-volume", false, "Match only volume sources of pods and workloads: configMap, secret, persistentVolumeClaim and hostPath.")
+	flag.BoolVar(&application.FindPullSecret, "find-pull-secret", false, "Match only imagePullSecrets of pods, workloads and service accounts.")
+	flag.StringVar(&application.ControllerPresets, "controller-annotations", "", "Match only annotations used by controllers, comma separated presets. Options: argocd, flux, helm")
+	flag.BoolVar(&application.ManagedBy, "managed-by", false, "Match only names of field managers that modified object.")
+	flag.BoolVar(&application.FindFinalizer, "find-finalizer", false, "Match only finalizers of objects, useful to find what holds deletion.")
+	flag.StringVar(&application.Scope, "scope", application.Scope, "Part of objects to search in. Options: all, metadata (only values of labels and annotations), images (only images of containers), env (only environment variables of containers with referenced configmaps and secrets)")
+	flag.BoolVar(&application.Precise, "precise", false, "Match every field value separately and report path to matched field.")
+	flag.StringVar(&application.LocatorFormat, "locator-format", application.LocatorFormat, "Format of matched field path. Options: jsonpath, pointer")
+	flag.BoolVar(&application.LatestOnly, "latest-only", false, "Skip ReplicaSets of old revisions of deployments, only current revision from deployment.kubernetes.io/revision is searched.")
+	flag.BoolVar(&application.Orphans, "orphans", false, "Report only objects which owner references point to deleted owners.")
+	flag.StringVar(&application.Webhook, "webhook", "", "URL to POST results as JSON at the end of the run.")
+	flag.BoolVar(&application.GroupByApp, "group-by-app", false, "Group matching objects by application label.")
+	flag.StringVar(&application.AppLabel, "app-label", application.AppLabel, "Label with application name for -group-by-app.")
+	flag.BoolVar(&application.Quiet, "quiet", false, "Do not print matches, exit code is 0 when something found and 1 when nothing found.")
+	flag.BoolVar(&application.Pretty, "pretty", false, "Indent json output for humans, by default it is compact for piping.")
+
+	flag.BoolVar(&application.Raw, "raw", false, "Search in Go representation of objects instead of yaml.")
+	flag.TextVar(&application.LogLevel, "log-level", slog.LevelInfo, "Log level: debug, info, warn or error, results are printed on any level.")
 
 	flag.Parse()
 
+	// -tails has default value, so only main knows it was set explicitly
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "tails" && application.ContextLines > 0 {
+			fatal(errors.New("tails and context-lines can not be used together"))
+		}
+	})
+
+	slog.SetLogLoggerLevel(application.LogLevel)
+
 	if err := application.Validate(); err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+
+	if application.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, application.Timeout)
+		defer cancel()
 	}
 
 	if err := application.Init(ctx); err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	if err := application.Run(ctx); err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
+
+	os.Exit(application.ExitCode())
+}
+
+// fatal exits with 2 on errors, 1 is used when nothing found.
+func fatal(err error) {
+	log.Print(err)
+	os.Exit(2)
 }
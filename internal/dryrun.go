@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// searchedKinds returns lowercased kinds which will be listed
+// with -where and -except-kind.
+func (a *Application) searchedKinds() []string {
+	kinds := slices.Sorted(maps.Values(kindAliases))
+
+	if a.gvr != nil {
+		kinds = append(kinds, strings.ToLower(a.gvr.GroupResource().String()))
+	}
+
+	return slices.DeleteFunc(kinds, func(kind string) bool {
+		return !a.isInWhere(kind) || a.isExceptKind(kind)
+	})
+}
+
+// printDryRun prints what will be searched without calling API.
+func (a *Application) printDryRun(w io.Writer) error {
+	namespaces := strings.Join(a.namespaces(), ",")
+	if namespaces == "" {
+		namespaces = "(all)"
+	}
+
+	lines := []string{
+		"kinds: " + strings.Join(a.searchedKinds(), ","),
+		"namespaces: " + namespaces,
+	}
+
+	// patterns are printed without flags like in Match.Pattern
+	flags := patternFlags(a.engine.CaseSensitive)
+
+	for _, pattern := range a.engine.Patterns {
+		lines = append(lines, "find: "+strings.TrimPrefix(pattern.String(), flags))
+	}
+
+	if a.engine.Except != nil {
+		lines = append(lines, "except: "+strings.TrimPrefix(a.engine.Except.String(), flags))
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return errors.Wrap(err, "error in fmt.Fprintln")
+		}
+	}
+
+	return nil
+}
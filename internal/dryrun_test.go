@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDryRun(t *testing.T) {
+	a := newTestApplication("registry.example.com", testPod("prod", "api", "registry.example.com/api:1.0"))
+	a.WhereToSearch = "po,deploy,cm"
+	a.ExceptKind = "configmaps"
+	a.Namespace = "prod,dev"
+	a.Except = "kube-system/.*"
+	a.DryRun = true
+
+	initApplication(t, a)
+
+	var buf bytes.Buffer
+
+	if err := a.printDryRun(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "kinds: deployments,pods\n" +
+		"namespaces: prod,dev\n" +
+		"find: registry.example.com\n" +
+		"except: kube-system/.*\n"
+
+	if buf.String() != want {
+		t.Errorf("expected dry run output %q, got %q", want, buf.String())
+	}
+
+	if err := a.Run(t.Context()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, action := range a.clientset.(*fake.Clientset).Actions() {
+		if action.GetVerb() == "list" {
+			t.Errorf("unexpected list of %s in dry run", action.GetResource().Resource)
+		}
+	}
+}
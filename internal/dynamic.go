@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// parseGVR parses -gvr in group/version/resource form, group is omitted for core resources.
+func parseGVR(value string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(value, "/")
+
+	switch {
+	case len(parts) == 3 && parts[1] != "" && parts[2] != "":
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	default:
+		return schema.GroupVersionResource{}, errors.Errorf("gvr %q must be in group/version/resource form", value)
+	}
+}
+
+// getCustomResources lists resource from -gvr with dynamic client.
+func (a *Application) getCustomResources(ctx context.Context) error {
+	if a.gvr == nil {
+		return nil
+	}
+
+	typeOf := a.gvr.GroupResource().String()
+
+	list := func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.dynamicClient.Resource(*a.gvr).Namespace(namespace).List(ctx, opts)
+	}
+
+	if !a.isNamespaced(*a.gvr) {
+		return a.listClusterScoped(ctx, typeOf, list)
+	}
+
+	return a.list(ctx, typeOf, list)
+}
+
+// isNamespaced reports whether resource belongs to namespace, resource
+// is assumed to be namespaced when discovery does not know it.
+func (a *Application) isNamespaced(gvr schema.GroupVersionResource) bool {
+	resources, err := a.clientset.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return true
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return resource.Namespaced
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseGVR(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    schema.GroupVersionResource
+		wantErr bool
+	}{
+		{"cert-manager.io/v1/certificates", schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, false},
+		{"v1/pods", schema.GroupVersionResource{Version: "v1", Resource: "pods"}, false},
+		{"certificates", schema.GroupVersionResource{}, true},
+		{"cert-manager.io//certificates", schema.GroupVersionResource{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGVR(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q: expected %v (error %v), got %v (%v)", tt.value, tt.want, tt.wantErr, got, err)
+		}
+	}
+}
+
+func testCustomResource(kind, namespace, name, spec string) *unstructured.Unstructured {
+	item := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"value": spec},
+	}}
+	item.SetAPIVersion("cert-manager.io/v1")
+	item.SetKind(kind)
+	item.SetNamespace(namespace)
+	item.SetName(name)
+
+	return item
+}
+
+func TestCustomResources(t *testing.T) {
+	objects := []runtime.Object{
+		testCustomResource("Certificate", "prod", "shop", "shop.example.com"),
+		testCustomResource("Certificate", "dev", "shop", "shop.example.com"),
+		testCustomResource("ClusterIssuer", "", "letsencrypt", "shop.example.com"),
+	}
+
+	tests := []struct {
+		gvr  string
+		want []string
+	}{
+		{"cert-manager.io/v1/certificates", []string{"certificates.cert-manager.io/prod/shop"}},
+		// cluster scoped resources are listed in all namespaces
+		{"cert-manager.io/v1/clusterissuers", []string{"clusterissuers.cert-manager.io//letsencrypt"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("example.com")
+		a.GVR = tt.gvr
+		a.Namespace = "prod"
+
+		a.clientset.(*fake.Clientset).Resources = []*metav1.APIResourceList{{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", Namespaced: true},
+				{Name: "clusterissuers", Namespaced: false},
+			},
+		}}
+
+		a.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}:   "CertificateList",
+			{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}: "ClusterIssuerList",
+		}, objects...)
+
+		if got := matchedObjects(findMatches(t, a)); !slices.Equal(slices.Compact(got), tt.want) {
+			t.Errorf("gvr %s: expected %v, got %v", tt.gvr, tt.want, got)
+		}
+	}
+}
+
+func TestCustomResourcesWithoutDynamicClient(t *testing.T) {
+	for _, modify := range []func(a *Application){
+		func(a *Application) { a.GVR = "cert-manager.io/v1/certificates" },
+		func(a *Application) { a.Watch = true },
+	} {
+		a := newTestApplication("example.com")
+		modify(a)
+
+		if err := a.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+
+		if err := a.Init(t.Context()); err == nil || !strings.Contains(err.Error(), "dynamic client is required") {
+			t.Errorf("expected error about missing dynamic client, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package internal
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// SearchEngine finds pattern in text of objects, it does not depend
+// on where objects come from.
+type SearchEngine struct {
+	Patterns      []*regexp.Regexp
+	Except        *regexp.Regexp
+	Redact        *regexp.Regexp
+	ShowTails     int
+	Squeeze       bool
+	CaseSensitive bool
+	Highlight     bool
+	ExceptFormat  string
+	ContextLines  int
+}
+
+// formats of text matched by except pattern.
+const (
+	ExceptFormatName = "namespace/name"
+	ExceptFormatKind = "kind/namespace/name"
+)
+
+var exceptFormats = []string{ExceptFormatName, ExceptFormatKind}
+
+var (
+	whitespaceRe     = regexp.MustCompile(`\s+`)
+	lineWhitespaceRe = regexp.MustCompile(`[^\S\n]+`)
+)
+
+// colors of matched text like in grep.
+const (
+	highlightStart = "\x1b[01;31m"
+	highlightEnd   = "\x1b[0m"
+)
+
+// caseInsensitive is prepended to patterns matched against objects,
+// so uppercase in pattern matches the same way in body and in except,
+// it is omitted with -case-sensitive.
+const caseInsensitive = "(?i)"
+
+func patternFlags(caseSensitive bool) string {
+	if caseSensitive {
+		return ""
+	}
+
+	return caseInsensitive
+}
+
+// wordPattern makes pattern match only on word boundaries like grep -w,
+// anchors already present in pattern are kept as is.
+func wordPattern(pattern string) string {
+	start, end := `\b`, `\b`
+
+	if strings.HasPrefix(pattern, "^") || strings.HasPrefix(pattern, `\b`) {
+		start = ""
+	}
+
+	if (strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`)) || strings.HasSuffix(pattern, `\b`) {
+		end = ""
+	}
+
+	return start + "(?:" + pattern + ")" + end
+}
+
+// NewSearchEngine compiles patterns, except and redact are optional.
+// Patterns and except are case insensitive unless caseSensitive is set.
+func NewSearchEngine(patterns []string, except, redact string, showTails int, caseSensitive bool) (*SearchEngine, error) {
+	engine := &SearchEngine{
+		ShowTails:     showTails,
+		CaseSensitive: caseSensitive,
+	}
+
+	flags := patternFlags(caseSensitive)
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(flags + pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "error in regexp.Compile "+pattern)
+		}
+
+		engine.Patterns = append(engine.Patterns, re)
+	}
+
+	var err error
+
+	if except != "" {
+		engine.Except, err = regexp.Compile(flags + except)
+		if err != nil {
+			return nil, errors.Wrap(err, "error in regexp.Compile "+except)
+		}
+	}
+
+	if redact != "" {
+		engine.Redact, err = regexp.Compile(redact)
+		if err != nil {
+			return nil, errors.Wrap(err, "error in regexp.Compile "+redact)
+		}
+	}
+
+	return engine, nil
+}
+
+// Excluded reports whether object must be skipped by except pattern,
+// pattern is matched against namespace/name or kind/namespace/name.
+func (e *SearchEngine) Excluded(kind, name, namespace string) bool {
+	if e.Except == nil {
+		return false
+	}
+
+	target := namespace + "/" + name
+	if e.ExceptFormat == ExceptFormatKind {
+		target = kind + "/" + target
+	}
+
+	return e.Except.MatchString(target)
+}
+
+// Search returns all matches of any pattern in body with surrounding text,
+// when there are several patterns every match reports its pattern.
+// Excluded objects must be skipped by caller.
+func (e *SearchEngine) Search(name, namespace, body string) []Match {
+	lowered, offsets := body, []int(nil)
+	if !e.CaseSensitive {
+		lowered, offsets = toLower(body)
+	}
+
+	matches := make([]Match, 0)
+	spans := e.redactSpans(body)
+
+	for _, pattern := range e.Patterns {
+		for _, loc := range pattern.FindAllStringIndex(lowered, -1) {
+			// offsets in original body, lowercasing can change length of runes
+			if offsets != nil {
+				loc = []int{offsets[loc[0]], offsets[loc[1]]}
+			}
+
+			text, matched := e.snippet(body, loc, spans)
+
+			match := Match{
+				Name:       name,
+				Namespace:  namespace,
+				Text:       text,
+				Match:      matched,
+				Offset:     loc[0],
+				RuneOffset: utf8.RuneCountInString(body[:loc[0]]),
+				original:   body[loc[0]:loc[1]],
+			}
+
+			if len(e.Patterns) > 1 {
+				match.Pattern = strings.TrimPrefix(pattern.String(), patternFlags(e.CaseSensitive))
+			}
+
+			matches = append(matches, match)
+		}
+	}
+
+	if len(e.Patterns) > 1 {
+		slices.SortStableFunc(matches, func(x, y Match) int {
+			return x.Offset - y.Offset
+		})
+	}
+
+	return matches
+}
+
+// Contains reports whether text contains substr with case sensitivity of patterns.
+func (e *SearchEngine) Contains(text, substr string) bool {
+	if e.CaseSensitive {
+		return strings.Contains(text, substr)
+	}
+
+	return strings.Contains(strings.ToLower(text), strings.ToLower(substr))
+}
+
+// toLower returns lowercased text and offset in text for every byte
+// of lowercased text (and its end).
+func toLower(text string) (string, []int) {
+	var b strings.Builder
+
+	b.Grow(len(text))
+
+	offsets := make([]int, 0, len(text)+1)
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		before := b.Len()
+
+		if r == utf8.RuneError && size == 1 {
+			b.WriteByte(text[i])
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+
+		for range b.Len() - before {
+			offsets = append(offsets, i)
+		}
+
+		i += size
+	}
+
+	offsets = append(offsets, len(text))
+
+	return b.String(), offsets
+}
+
+// snippet returns text around match and matched text, parts of them
+// in redacted spans of body are masked.
+func (e *SearchEngine) snippet(body string, loc []int, spans [][]int) (string, string) {
+	start, end := e.window(body, loc)
+
+	text := e.clean(mask(body, start, end, spans))
+	matched := mask(body, loc[0], loc[1], spans)
+
+	if e.Highlight {
+		text = e.highlight(text,
+			e.clean(mask(body, start, loc[0], spans)),
+			e.clean(matched),
+			e.clean(mask(body, loc[1], end, spans)),
+		)
+	}
+
+	return text, matched
+}
+
+// window returns bounds of snippet around match, ShowTails bytes
+// or ContextLines whole lines around lines of match.
+func (e *SearchEngine) window(body string, loc []int) (int, int) {
+	if e.ContextLines > 0 {
+		return lineWindow(body, loc, e.ContextLines)
+	}
+
+	start := loc[0] - e.ShowTails
+	end := loc[1] + e.ShowTails
+
+	if start < 0 {
+		start = 0
+	}
+
+	if max := len(body); end > max {
+		end = max
+	}
+
+	// do not cut multi-byte runes at edges of snippet
+	for start > 0 && !utf8.RuneStart(body[start]) {
+		start--
+	}
+
+	for end < len(body) && !utf8.RuneStart(body[end]) {
+		end++
+	}
+
+	return start, end
+}
+
+// lineWindow returns bounds of lines of match with lines around it like grep -C.
+func lineWindow(body string, loc []int, lines int) (int, int) {
+	start := strings.LastIndexByte(body[:loc[0]], '\n') + 1
+
+	for range lines {
+		if start == 0 {
+			break
+		}
+
+		start = strings.LastIndexByte(body[:start-1], '\n') + 1
+	}
+
+	lineEnd := func(from int) int {
+		if i := strings.IndexByte(body[from:], '\n'); i >= 0 {
+			return from + i
+		}
+
+		return len(body)
+	}
+
+	end := lineEnd(loc[1])
+
+	for range lines {
+		// empty line after trailing newline is not context
+		if end >= len(body)-1 {
+			break
+		}
+
+		end = lineEnd(end + 1)
+	}
+
+	return start, end
+}
+
+// clean makes snippet one line, with ContextLines lines are kept.
+func (e *SearchEngine) clean(text string) string {
+	if e.ContextLines > 0 {
+		if e.Squeeze {
+			text = lineWhitespaceRe.ReplaceAllString(text, " ")
+		}
+
+		return text
+	}
+
+	text = strings.ReplaceAll(text, "\n", " ")
+
+	if e.Squeeze {
+		text = whitespaceRe.ReplaceAllString(text, " ")
+	}
+
+	return text
+}
+
+// redactSpans returns bounds of redacted parts of whole body, they are
+// found before body is cut to snippet, so secret cut by edge of snippet
+// is masked too.
+func (e *SearchEngine) redactSpans(body string) [][]int {
+	if e.Redact == nil {
+		return nil
+	}
+
+	return e.Redact.FindAllStringIndex(body, -1)
+}
+
+// mask returns body[start:end] with every part overlapping spans replaced by ***.
+func mask(body string, start, end int, spans [][]int) string {
+	var b strings.Builder
+
+	from := start
+
+	for _, span := range spans {
+		if span[0] == span[1] || span[1] <= start || span[0] >= end {
+			continue
+		}
+
+		if span[0] > from {
+			b.WriteString(body[from:span[0]])
+		}
+
+		b.WriteString("***")
+
+		from = min(span[1], end)
+	}
+
+	b.WriteString(body[from:end])
+
+	return b.String()
+}
+
+func (e *SearchEngine) redact(text string) string {
+	if e.Redact == nil {
+		return text
+	}
+
+	return e.Redact.ReplaceAllString(text, "***")
+}
+
+// highlight colors matched part of text, when parts cleaned separately
+// differ from text (redaction or whitespace across edges of match)
+// text is returned as is, so redaction is never weakened.
+func (e *SearchEngine) highlight(text, before, matched, after string) string {
+	if before+matched+after != text {
+		return text
+	}
+
+	return before + highlightStart + matched + highlightEnd + after
+}
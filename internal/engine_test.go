@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRedactCutBySnippet(t *testing.T) {
+	engine, err := NewSearchEngine([]string{"token"}, "", "secret-[a-z0-9]{10}", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := engine.Search("name", "ns", "auth: token=secret-abcdef1234 end")
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	if text := matches[0].Text; strings.Contains(text, "secret") || !strings.Contains(text, "***") {
+		t.Errorf("expected secret cut by snippet to be masked, got %q", text)
+	}
+}
+
+func TestRedactOverlappingMatch(t *testing.T) {
+	engine, err := NewSearchEngine([]string{"abcdef"}, "", "secret-[a-z0-9]{10}", 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine.Highlight = true
+
+	matches := engine.Search("name", "ns", "token=secret-abcdef1234 end")
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	if match := matches[0]; match.Match != "***" || strings.Contains(match.Text, "abcdef") {
+		t.Errorf("expected match in secret to be masked, got %q in %q", match.Match, match.Text)
+	}
+}
+
+func TestOffsetsAfterCaseFolding(t *testing.T) {
+	tests := []struct {
+		body    string
+		pattern string
+	}{
+		// İ is 2 bytes, lowercased to i it is 1 byte
+		{"city: İstanbul token: abc", "token"},
+		{"İİİ stanbul", "stanbul"},
+		// K (Kelvin sign) is 3 bytes, lowercased it is 1 byte
+		{"unit: K token: abc", "token"},
+		{"ẞtraße token", "token"},
+	}
+
+	for _, tt := range tests {
+		engine, err := NewSearchEngine([]string{tt.pattern}, "", "", 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		matches := engine.Search("name", "ns", tt.body)
+		if len(matches) != 1 {
+			t.Fatalf("%q: expected 1 match, got %d", tt.body, len(matches))
+		}
+
+		offset := strings.Index(tt.body, tt.pattern)
+		match := matches[0]
+
+		if match.Offset != offset || tt.body[match.Offset:match.Offset+len(match.Match)] != tt.pattern {
+			t.Errorf("%q: expected byte offset %d in original body, got %d", tt.body, offset, match.Offset)
+		}
+
+		if want := utf8.RuneCountInString(tt.body[:offset]); match.RuneOffset != want {
+			t.Errorf("%q: expected rune offset %d, got %d", tt.body, want, match.RuneOffset)
+		}
+	}
+}
+
+func TestMatchOfChangedLengthRune(t *testing.T) {
+	engine, err := NewSearchEngine([]string{"istanbul"}, "", "", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := engine.Search("name", "ns", "city: İstanbul")
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	if match := matches[0]; match.Match != "İstanbul" || match.Offset != 6 || match.RuneOffset != 6 {
+		t.Errorf("expected İstanbul at 6, got %q at %d (rune %d)", match.Match, match.Offset, match.RuneOffset)
+	}
+}
+
+func TestExceptCaseSensitivity(t *testing.T) {
+	tests := []struct {
+		except        string
+		caseSensitive bool
+		excluded      bool
+	}{
+		{"Prod/Web", false, true},
+		{"prod/web", false, true},
+		{"Prod/Web", true, true},
+		{"prod/web", true, false},
+	}
+
+	for _, tt := range tests {
+		engine, err := NewSearchEngine([]string{"nginx"}, tt.except, "", 10, tt.caseSensitive)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := engine.Excluded("Pods", "Web", "Prod"); got != tt.excluded {
+			t.Errorf("except %q case-sensitive %v: expected excluded %v, got %v", tt.except, tt.caseSensitive, tt.excluded, got)
+		}
+	}
+}
+
+func TestPatternCaseSensitivityMatchesExcept(t *testing.T) {
+	// the same uppercase pattern matches body and except alike
+	engine, err := NewSearchEngine([]string{"Prod"}, "Prod/.*", "", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(engine.Search("web", "prod", "namespace: prod")) != 1 {
+		t.Error("expected uppercase pattern to match lowercase body")
+	}
+
+	if !engine.Excluded("Pods", "web", "prod") {
+		t.Error("expected uppercase except to match lowercase namespace")
+	}
+}
+
+func TestSnippetRuneSafe(t *testing.T) {
+	body := "名前🙂🙂 token\n値🙂🙂"
+
+	for tails := range 8 {
+		engine, err := NewSearchEngine([]string{"token"}, "", "", tails, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		matches := engine.Search("name", "ns", body)
+		if len(matches) != 1 {
+			t.Fatalf("expected 1 match, got %d", len(matches))
+		}
+
+		text := matches[0].Text
+
+		if !utf8.ValidString(text) || !strings.Contains(text, "token") {
+			t.Errorf("tails %d: expected valid snippet with match, got %q", tails, text)
+		}
+
+		if strings.Contains(text, "\n") {
+			t.Errorf("tails %d: expected new line replaced with space, got %q", tails, text)
+		}
+	}
+}
+
+func TestShowTails(t *testing.T) {
+	body := "0123456789 token 0123456789"
+
+	tests := []struct {
+		tails int
+		want  string
+	}{
+		{0, "token"},
+		{3, "89 token 01"},
+		{100, body},
+	}
+
+	for _, tt := range tests {
+		engine, err := NewSearchEngine([]string{"token"}, "", "", tt.tails, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if text := engine.Search("name", "ns", body)[0].Text; text != tt.want {
+			t.Errorf("tails %d: expected %q, got %q", tt.tails, tt.want, text)
+		}
+	}
+
+	a := newTestApplication("token")
+	a.ShowTails = -1
+
+	if err := a.Validate(); err == nil {
+		t.Error("expected negative tails to be invalid")
+	}
+}
+
+func TestColorHighlightsMatchedBytes(t *testing.T) {
+	const image = "Registry.Example.com/api:1.0"
+
+	tests := []struct {
+		color string
+		want  bool
+	}{
+		{ColorAlways, true},
+		{ColorNever, false},
+		// output is not terminal in tests
+		{ColorAuto, false},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("registry.example.com", testPod("prod", "api", image))
+		a.Color = tt.color
+
+		matches := findMatches(t, a)
+		if len(matches) != 1 {
+			t.Fatalf("%s: expected 1 match, got %d", tt.color, len(matches))
+		}
+
+		text := matches[0].Text
+		colored := highlightStart + "Registry.Example.com" + highlightEnd + "/api:1.0"
+
+		if got := strings.Contains(text, colored); got != tt.want {
+			t.Errorf("%s: expected colored %v, got %q", tt.color, tt.want, text)
+		}
+
+		if !tt.want && strings.Contains(text, "\x1b[") {
+			t.Errorf("%s: unexpected escape codes in %q", tt.color, text)
+		}
+
+		if stripped := strings.NewReplacer(highlightStart, "", highlightEnd, "").Replace(text); !strings.Contains(stripped, image) {
+			t.Errorf("%s: expected image in snippet, got %q", tt.color, text)
+		}
+	}
+}
+
+func TestColorNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	a := NewApplication()
+	if a.useColor() {
+		t.Error("expected NO_COLOR to disable color in auto mode")
+	}
+
+	a.Color = ColorAlways
+	if !a.useColor() {
+		t.Error("expected -color=always to override NO_COLOR")
+	}
+}
+
+func TestWord(t *testing.T) {
+	tests := []struct {
+		pattern string
+		body    string
+		want    []string
+	}{
+		{"api", "name: api", []string{"api"}},
+		{"api", "name: capital apiserver", nil},
+		{"api", "host: api.example.com", []string{"api"}},
+		// anchored patterns keep their anchors
+		{"^api", "api capital", []string{"api"}},
+		{"api|web", "capital web", []string{"web"}},
+	}
+
+	for _, tt := range tests {
+		a := NewApplication()
+		a.WhatToSearch = []string{tt.pattern}
+		a.Word = true
+
+		engine, err := NewSearchEngine(a.patterns(), "", "", 10, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]string, 0)
+		for _, match := range engine.Search("name", "ns", tt.body) {
+			got = append(got, tt.body[match.Offset:match.Offset+len(match.Match)])
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q in %q: expected %v, got %v", tt.pattern, tt.body, tt.want, got)
+		}
+	}
+}
+
+func TestContextLines(t *testing.T) {
+	body := "apiVersion: v1\nkind: ConfigMap\ndata:\n  host: db.example.com\n  port: \"5432\"\n  user: app\nmetadata:\n  name: db\n"
+
+	tests := []struct {
+		lines int
+		want  string
+	}{
+		{1, "data:\n  host: db.example.com\n  port: \"5432\""},
+		{2, "kind: ConfigMap\ndata:\n  host: db.example.com\n  port: \"5432\"\n  user: app"},
+		// window stops at first and last line of object
+		{10, strings.TrimSuffix(body, "\n")},
+	}
+
+	for _, tt := range tests {
+		engine, err := NewSearchEngine([]string{"db.example"}, "", "", 5, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		engine.ContextLines = tt.lines
+
+		matches := engine.Search("db", "prod", body)
+		if len(matches) != 1 {
+			t.Fatalf("expected 1 match, got %d", len(matches))
+		}
+
+		if matches[0].Text != tt.want {
+			t.Errorf("context %d: expected %q, got %q", tt.lines, tt.want, matches[0].Text)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	LocatorJSONPath = "jsonpath"
+	LocatorPointer  = "pointer"
+)
+
+var locatorFormats = []string{LocatorJSONPath, LocatorPointer}
+
+const (
+	ScopeAll      = "all"
+	ScopeMetadata = "metadata"
+	ScopeImages   = "images"
+	ScopeEnv      = "env"
+)
+
+var scopes = []string{ScopeAll, ScopeMetadata, ScopeImages, ScopeEnv}
+
+// fieldPath is a location in object, elements are map keys (string)
+// or list indexes (int).
+type fieldPath []any
+
+func (p fieldPath) child(elems ...any) fieldPath {
+	return append(slices.Clone(p), elems...)
+}
+
+// JSONPath formats path like spec.containers[0].image.
+func (p fieldPath) JSONPath() string {
+	var b strings.Builder
+
+	for _, elem := range p {
+		switch e := elem.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(e) + "]")
+		case string:
+			if strings.ContainsAny(e, "./[]") {
+				b.WriteString("['" + e + "']")
+
+				continue
+			}
+
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+
+			b.WriteString(e)
+		}
+	}
+
+	return b.String()
+}
+
+// Pointer formats path as RFC 6901 JSON Pointer like /spec/containers/0/image.
+func (p fieldPath) Pointer() string {
+	var b strings.Builder
+
+	for _, elem := range p {
+		b.WriteString("/")
+
+		switch e := elem.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(e))
+		case string:
+			e = strings.ReplaceAll(e, "~", "~0")
+			e = strings.ReplaceAll(e, "/", "~1")
+
+			b.WriteString(e)
+		}
+	}
+
+	return b.String()
+}
+
+func (p fieldPath) format(locator string) string {
+	if locator == LocatorPointer {
+		return p.Pointer()
+	}
+
+	return p.JSONPath()
+}
+
+// field is a single value extracted from object with its location.
+type field struct {
+	Path  fieldPath
+	Value string
+}
+
+// podSpec returns pod spec of pod or workload template with path to it.
+func podSpec(obj runtime.Object) (*corev1.PodSpec, fieldPath) {
+	templatePath := fieldPath{"spec", "template", "spec"}
+
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		if o != nil {
+			return &o.Spec, fieldPath{"spec"}
+		}
+	case *appsv1.Deployment:
+		if o != nil {
+			return &o.Spec.Template.Spec, templatePath
+		}
+	case *appsv1.StatefulSet:
+		if o != nil {
+			return &o.Spec.Template.Spec, templatePath
+		}
+	case *appsv1.DaemonSet:
+		if o != nil {
+			return &o.Spec.Template.Spec, templatePath
+		}
+	case *appsv1.ReplicaSet:
+		if o != nil {
+			return &o.Spec.Template.Spec, templatePath
+		}
+	case *batchv1.Job:
+		if o != nil {
+			return &o.Spec.Template.Spec, templatePath
+		}
+	case *batchv1.CronJob:
+		if o != nil {
+			return &o.Spec.JobTemplate.Spec.Template.Spec, fieldPath{"spec", "jobTemplate", "spec", "template", "spec"}
+		}
+	}
+
+	return nil, nil
+}
+
+// imageFields returns images of all containers of pod or workload template.
+func imageFields(obj runtime.Object) []field {
+	spec, path := podSpec(obj)
+	if spec == nil {
+		return nil
+	}
+
+	fields := make([]field, 0)
+
+	for i, container := range spec.InitContainers {
+		fields = append(fields, field{path.child("initContainers", i, "image"), container.Image})
+	}
+
+	for i, container := range spec.Containers {
+		fields = append(fields, field{path.child("containers", i, "image"), container.Image})
+	}
+
+	for i, container := range spec.EphemeralContainers {
+		fields = append(fields, field{path.child("ephemeralContainers", i, "image"), container.Image})
+	}
+
+	return fields
+}
+
+// envFields returns names, values and referenced configmaps and secrets
+// of environment variables of all containers of pod or workload template.
+func envFields(obj runtime.Object) []field {
+	spec, path := podSpec(obj)
+	if spec == nil {
+		return nil
+	}
+
+	fields := make([]field, 0)
+
+	env := func(containerPath fieldPath, env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for j, env := range env {
+			fields = append(fields, containerEnvFields(containerPath.child("env", j), env)...)
+		}
+
+		for j, envFrom := range envFrom {
+			envFromPath := containerPath.child("envFrom", j)
+
+			if envFrom.ConfigMapRef != nil {
+				fields = append(fields, field{envFromPath.child("configMapRef", "name"), envFrom.ConfigMapRef.Name})
+			}
+
+			if envFrom.SecretRef != nil {
+				fields = append(fields, field{envFromPath.child("secretRef", "name"), envFrom.SecretRef.Name})
+			}
+		}
+	}
+
+	containers := func(name string, containers []corev1.Container) {
+		for i, container := range containers {
+			env(path.child(name, i), container.Env, container.EnvFrom)
+		}
+	}
+
+	containers("initContainers", spec.InitContainers)
+	containers("containers", spec.Containers)
+
+	for i, container := range spec.EphemeralContainers {
+		env(path.child("ephemeralContainers", i), container.Env, container.EnvFrom)
+	}
+
+	return fields
+}
+
+func containerEnvFields(path fieldPath, env corev1.EnvVar) []field {
+	fields := []field{{path.child("name"), env.Name}}
+
+	if env.Value != "" {
+		fields = append(fields, field{path.child("value"), env.Value})
+	}
+
+	if env.ValueFrom == nil {
+		return fields
+	}
+
+	if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+		refPath := path.child("valueFrom", "configMapKeyRef")
+		fields = append(fields, field{refPath.child("name"), ref.Name}, field{refPath.child("key"), ref.Key})
+	}
+
+	if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+		refPath := path.child("valueFrom", "secretKeyRef")
+		fields = append(fields, field{refPath.child("name"), ref.Name}, field{refPath.child("key"), ref.Key})
+	}
+
+	return fields
+}
+
+func volumeFields(obj runtime.Object) []field {
+	spec, path := podSpec(obj)
+	if spec == nil {
+		return nil
+	}
+
+	fields := make([]field, 0)
+
+	for i, volume := range spec.Volumes {
+		volumePath := path.child("volumes", i)
+
+		switch {
+		case volume.ConfigMap != nil:
+			fields = append(fields, field{volumePath.child("configMap", "name"), volume.ConfigMap.Name})
+		case volume.Secret != nil:
+			fields = append(fields, field{volumePath.child("secret", "secretName"), volume.Secret.SecretName})
+		case volume.PersistentVolumeClaim != nil:
+			fields = append(fields, field{volumePath.child("persistentVolumeClaim", "claimName"), volume.PersistentVolumeClaim.ClaimName})
+		case volume.HostPath != nil:
+			fields = append(fields, field{volumePath.child("hostPath", "path"), volume.HostPath.Path})
+		case volume.Projected != nil:
+			for j, source := range volume.Projected.Sources {
+				sourcePath := volumePath.child("projected", "sources", j)
+
+				if source.ConfigMap != nil {
+					fields = append(fields, field{sourcePath.child("configMap", "name"), source.ConfigMap.Name})
+				}
+
+				if source.Secret != nil {
+					fields = append(fields, field{sourcePath.child("secret", "name"), source.Secret.Name})
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+func pullSecretFields(obj runtime.Object) []field {
+	if serviceAccount, ok := obj.(*corev1.ServiceAccount); ok && serviceAccount != nil {
+		fields := make([]field, 0, len(serviceAccount.ImagePullSecrets))
+
+		for i, secret := range serviceAccount.ImagePullSecrets {
+			fields = append(fields, field{fieldPath{"imagePullSecrets", i, "name"}, secret.Name})
+		}
+
+		return fields
+	}
+
+	spec, path := podSpec(obj)
+	if spec == nil {
+		return nil
+	}
+
+	fields := make([]field, 0, len(spec.ImagePullSecrets))
+
+	for i, secret := range spec.ImagePullSecrets {
+		fields = append(fields, field{path.child("imagePullSecrets", i, "name"), secret.Name})
+	}
+
+	return fields
+}
+
+// portFields returns container, service and ingress backend port numbers.
+func portFields(obj runtime.Object) []field {
+	fields := make([]field, 0)
+
+	port := func(path fieldPath, number int32) {
+		if number > 0 {
+			fields = append(fields, field{path, strconv.Itoa(int(number))})
+		}
+	}
+
+	switch o := obj.(type) {
+	case *corev1.Service:
+		if o == nil {
+			break
+		}
+
+		for i, servicePort := range o.Spec.Ports {
+			path := fieldPath{"spec", "ports", i}
+
+			port(path.child("port"), servicePort.Port)
+			port(path.child("nodePort"), servicePort.NodePort)
+
+			if servicePort.TargetPort.Type == intstr.Int {
+				port(path.child("targetPort"), servicePort.TargetPort.IntVal)
+			}
+		}
+	case *networkingv1.Ingress:
+		if o == nil {
+			break
+		}
+
+		if backend := o.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+			port(fieldPath{"spec", "defaultBackend", "service", "port", "number"}, backend.Service.Port.Number)
+		}
+
+		for i, rule := range o.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			for j, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil {
+					port(fieldPath{"spec", "rules", i, "http", "paths", j, "backend", "service", "port", "number"}, path.Backend.Service.Port.Number)
+				}
+			}
+		}
+	default:
+		spec, path := podSpec(obj)
+		if spec == nil {
+			break
+		}
+
+		for i, container := range spec.InitContainers {
+			for j, containerPort := range container.Ports {
+				port(path.child("initContainers", i, "ports", j, "containerPort"), containerPort.ContainerPort)
+			}
+		}
+
+		for i, container := range spec.Containers {
+			for j, containerPort := range container.Ports {
+				port(path.child("containers", i, "ports", j, "containerPort"), containerPort.ContainerPort)
+				port(path.child("containers", i, "ports", j, "hostPort"), containerPort.HostPort)
+			}
+		}
+	}
+
+	return fields
+}
+
+var controllerAnnotations = map[string][]string{
+	"argocd": {"argocd.argoproj.io/"},
+	"flux":   {"fluxcd.io/", "kustomize.toolkit.fluxcd.io/", "helm.toolkit.fluxcd.io/"},
+	"helm":   {"meta.helm.sh/", "helm.sh/"},
+}
+
+// annotationFields returns annotations which keys start with one of prefixes.
+func annotationFields(obj runtime.Object, prefixes []string) ([]field, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in meta.Accessor")
+	}
+
+	annotations := object.GetAnnotations()
+	fields := make([]field, 0)
+
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		if !slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+			continue
+		}
+
+		fields = append(fields, field{fieldPath{"metadata", "annotations", key}, annotations[key]})
+	}
+
+	return fields, nil
+}
+
+// metadataFields returns values of labels and annotations captured at fetch time.
+func metadataFields(obj KubernetesObject) []field {
+	fields := make([]field, 0, len(obj.Labels)+len(obj.Annotations))
+
+	for _, key := range slices.Sorted(maps.Keys(obj.Labels)) {
+		fields = append(fields, field{fieldPath{"metadata", "labels", key}, obj.Labels[key]})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(obj.Annotations)) {
+		fields = append(fields, field{fieldPath{"metadata", "annotations", key}, obj.Annotations[key]})
+	}
+
+	return fields
+}
+
+func managerFields(obj runtime.Object) ([]field, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in meta.Accessor")
+	}
+
+	fields := make([]field, 0)
+
+	for i, managedField := range object.GetManagedFields() {
+		fields = append(fields, field{fieldPath{"metadata", "managedFields", i, "manager"}, managedField.Manager})
+	}
+
+	return fields, nil
+}
+
+func finalizerFields(obj runtime.Object) ([]field, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in meta.Accessor")
+	}
+
+	fields := make([]field, 0)
+
+	for i, finalizer := range object.GetFinalizers() {
+		fields = append(fields, field{fieldPath{"metadata", "finalizers", i}, finalizer})
+	}
+
+	return fields, nil
+}
+
+// leafFields returns every scalar value of object, values of secret
+// data are decoded like in searched text of object.
+func leafFields(obj runtime.Object) ([]field, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret, ok := obj.(*corev1.Secret); ok && len(secret.Data) > 0 {
+		data := make(map[string]any, len(secret.Data))
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+
+		content["data"] = data
+	}
+
+	fields := make([]field, 0)
+
+	walkLeafs(fieldPath{}, content, &fields)
+
+	return fields, nil
+}
+
+func walkLeafs(path fieldPath, value any, fields *[]field) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			walkLeafs(path.child(key), v[key], fields)
+		}
+	case []any:
+		for i, item := range v {
+			walkLeafs(path.child(i), item, fields)
+		}
+	case nil:
+	default:
+		*fields = append(*fields, field{path, fmt.Sprint(v)})
+	}
+}
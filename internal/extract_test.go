@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testDebugPod() *corev1.Pod {
+	pod := testPod("prod", "web", "nginx")
+	pod.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:  "debug",
+			Image: "busybox",
+			Env: []corev1.EnvVar{{
+				Name: "TOKEN",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "debug-token"},
+						Key:                  "token",
+					},
+				},
+			}},
+			EnvFrom: []corev1.EnvFromSource{{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "debug-config"},
+				},
+			}},
+		},
+	}}
+
+	return pod
+}
+
+func TestEnvFieldsEphemeralContainers(t *testing.T) {
+	paths := make([]string, 0)
+	for _, f := range envFields(testDebugPod()) {
+		paths = append(paths, f.Path.JSONPath()+"="+f.Value)
+	}
+
+	want := []string{
+		"spec.ephemeralContainers[0].env[0].name=TOKEN",
+		"spec.ephemeralContainers[0].env[0].valueFrom.secretKeyRef.name=debug-token",
+		"spec.ephemeralContainers[0].env[0].valueFrom.secretKeyRef.key=token",
+		"spec.ephemeralContainers[0].envFrom[0].configMapRef.name=debug-config",
+	}
+
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+}
+
+func TestReferencesEphemeralContainers(t *testing.T) {
+	a := newTestApplication("", testDebugPod())
+	a.WhatToSearch = nil
+	a.References = "secret/debug-token"
+	a.WhereToSearch = "pods"
+
+	if got := matchedObjects(findMatches(t, a)); !slices.Equal(got, []string{"Pods/prod/web"}) {
+		t.Errorf("expected pod referencing secret from ephemeral container, got %v", got)
+	}
+}
+
+func TestScopeMetadata(t *testing.T) {
+	labeled := testPod("prod", "labeled", "nginx")
+	labeled.Labels = map[string]string{"team": "payments"}
+
+	annotated := testPod("prod", "annotated", "nginx")
+	annotated.Annotations = map[string]string{"owner": "payments@example.com"}
+
+	a := newTestApplication("payments",
+		labeled,
+		annotated,
+		testPod("prod", "spec-only", "registry.example.com/payments:1.0"),
+	)
+	a.WhereToSearch = "pods"
+	a.Scope = ScopeMetadata
+
+	got := slices.Sorted(slices.Values(matchedObjects(findMatches(t, a))))
+	if want := []string{"Pods/prod/annotated", "Pods/prod/labeled"}; !slices.Equal(got, want) {
+		t.Errorf("expected only objects with value in metadata, got %v", got)
+	}
+}
+
+func TestScopeImages(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "shop"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "migrate", Image: "registry.example.com/migrate:1.0"},
+					},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "registry.example.com/shop:1.0"},
+						{
+							Name:  "proxy",
+							Image: "envoyproxy/envoy:1.30",
+							Env:   []corev1.EnvVar{{Name: "UPSTREAM", Value: "registry.example.com"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	a := newTestApplication("registry.example.com", deployment)
+	a.WhereToSearch = "deployments"
+	a.Scope = ScopeImages
+	// whole image is in snippet
+	a.ShowTails = 20
+
+	matches := findMatches(t, a)
+
+	got := make([]string, 0, len(matches))
+	for _, match := range matches {
+		got = append(got, match.Kind+"/"+match.Name+" "+match.Path+" "+match.Text)
+	}
+
+	want := []string{
+		"Deployments/shop spec.template.spec.initContainers[0].image registry.example.com/migrate:1.0",
+		"Deployments/shop spec.template.spec.containers[0].image registry.example.com/shop:1.0",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("expected only matching images %v, got %v", want, got)
+	}
+}
+
+func TestReferencesConfigMap(t *testing.T) {
+	configMapRef := corev1.LocalObjectReference{Name: "app-config"}
+
+	mounted := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "api", Image: "api"}},
+			Volumes: []corev1.Volume{{
+				Name:         "config",
+				VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: configMapRef}},
+			}},
+		}}},
+	}
+
+	envFrom := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "db"},
+		Spec: appsv1.StatefulSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "db",
+				Image:   "postgres",
+				EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: configMapRef}}},
+			}},
+		}}},
+	}
+
+	// secret with the same name is not a reference to configmap
+	secret := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "web",
+				Image:   "nginx",
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: configMapRef}}},
+			}},
+		}}},
+	}
+
+	a := newTestApplication("", mounted, envFrom, secret)
+	a.WhatToSearch = nil
+	a.References = "configmap/app-config"
+
+	matches := findMatches(t, a)
+
+	got := make([]string, 0, len(matches))
+	for _, match := range matches {
+		got = append(got, match.Kind+"/"+match.Name+" "+match.Path)
+	}
+
+	want := []string{
+		"Deployments/api spec.template.spec.volumes[0].configMap.name",
+		"StatefulSets/db spec.template.spec.containers[0].envFrom[0].configMapRef.name",
+	}
+
+	if !slices.Equal(slices.Sorted(slices.Values(got)), want) {
+		t.Errorf("expected workloads consuming configmap %v, got %v", want, got)
+	}
+}
+
+func TestEphemeralContainerImage(t *testing.T) {
+	for _, scope := range []string{ScopeAll, ScopeImages} {
+		a := newTestApplication("busybox", testDebugPod())
+		a.WhereToSearch = "pods"
+		a.Scope = scope
+
+		matches := findMatches(t, a)
+
+		paths := make([]string, 0, len(matches))
+		for _, match := range matches {
+			paths = append(paths, match.Path)
+		}
+
+		if !slices.Contains(paths, "spec.ephemeralContainers[0].image") {
+			t.Errorf("scope %s: expected match in ephemeral container image, got %v", scope, paths)
+		}
+	}
+}
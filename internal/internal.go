@@ -1,333 +1,1371 @@
 package internal
 
 import (
+	"cmp"
 	"context"
+	"io"
 	"log/slog"
-	"regexp"
+	"maps"
+	"os"
+	goruntime "runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 func NewApplication() *Application {
 	return &Application{
 		KubernetesObjects: make([]KubernetesObject, 0),
+		Matches:           make([]Match, 0),
 		ShowTails:         10,
+		Output:            OutputText,
+		Sample:            1,
+		LocatorFormat:     LocatorJSONPath,
+		AppLabel:          "app.kubernetes.io/name",
+		PageSize:          500,
+		Scope:             ScopeAll,
+		Color:             ColorAuto,
+		QPS:               50,
+		Burst:             100,
+		Retries:           3,
+		Delimiter:         ",",
+		ExceptFormat:      ExceptFormatName,
+		Workers:           goruntime.GOMAXPROCS(0),
 	}
 }
 
 type Application struct {
-	clientset         *kubernetes.Clientset
-	Kubeconfig        string
-	WhereToSearch     string
-	WhatToSearch      string
-	whatToSearchRe    *regexp.Regexp
-	Namespace         string
-	KubernetesObjects []KubernetesObject
-	ShowTails         int
-	Except            string
-	exceptRe          *regexp.Regexp
+	clientset             kubernetes.Interface
+	dynamicClient         dynamic.Interface
+	clusterHost           string
+	clusterContext        string
+	cluster               string
+	Kubeconfig            string
+	WhereToSearch         string
+	WhatToSearch          []string
+	Namespace             string
+	Node                  string
+	KubernetesObjects     []KubernetesObject
+	ShowTails             int
+	Except                string
+	Output                string
+	Pretty                bool
+	Rank                  bool
+	Orphans               bool
+	FindVolume            bool
+	FindPullSecret        bool
+	ManagedBy             bool
+	FindFinalizer         bool
+	Precise               bool
+	LocatorFormat         string
+	owners                map[types.UID]bool
+	revisions             map[types.UID]string
+	staleRevisions        int
+	ListTimeout           time.Duration
+	RedactPattern         string
+	engine                *SearchEngine
+	Matches               []Match
+	Webhook               string
+	Sample                float64
+	SampleSeed            uint64
+	sampleSeed            uint64
+	KindStats             []*KindStat
+	GroupByApp            bool
+	AppLabel              string
+	FakeFromDir           string
+	Squeeze               bool
+	ClusterLabel          string
+	Template              string
+	TemplateFile          string
+	template              *template.Template
+	CountObjects          bool
+	SkipTerminating       bool
+	OnlyTerminating       bool
+	LimitPerKind          int64
+	ResultWriter          ResultWriter
+	Port                  int
+	ControllerPresets     string
+	controllerPrefixes    []string
+	started               time.Time
+	ShortKind             bool
+	SinceResourceVersion  string
+	sinceResourceVersion  uint64
+	latestResourceVersion uint64
+	Path                  string
+	AllNamespaces         bool
+	mu                    sync.Mutex
+	PageSize              int64
+	Buffered              bool
+	objects               chan KubernetesObject
+	searchedObjects       int
+	CaseSensitive         bool
+	Invert                bool
+	Selector              string
+	FieldSelector         string
+	InCluster             bool
+	KubeContext           string
+	Count                 bool
+	Scope                 string
+	Timeout               time.Duration
+	GVR                   string
+	gvr                   *schema.GroupVersionResource
+	OutputFile            string
+	outputFile            *os.File
+	Quiet                 bool
+	ExceptKind            string
+	Strict                bool
+	Color                 string
+	QPS                   float64
+	Burst                 int
+	Retries               int
+	MaxMatches            int
+	Sort                  bool
+	LogLevel              slog.Level
+	Raw                   bool
+	DryRun                bool
+	MaxObjectSize         int
+	Word                  bool
+	Since                 time.Duration
+	References            string
+	referenceKind         string
+	referenceName         string
+	Watch                 bool
+	Delimiter             string
+	delimiter             rune
+	listed                map[string]bool
+	Progress              bool
+	progress              bool
+	ExceptFormat          string
+	ContextLines          int
+	Workers               int
+	WatchDebounce         time.Duration
+	LatestOnly            bool
+	MaxTotalRetries       int64
+	totalRetries          atomic.Int64
 }
 
 type KubernetesObject struct {
-	Kind      string
-	Name      string
-	Namespace string
-	Object    string
+	Kind            string
+	Name            string
+	Namespace       string
+	Object          string
+	OwnerReferences []metav1.OwnerReference
+	Raw             runtime.Object
+	Node            string
+	Labels          map[string]string
+	Annotations     map[string]string
+	DeletionTime    *metav1.Time
+	GVK             schema.GroupVersionKind
+	Created         time.Time
+}
+
+type Match struct {
+	Cluster      string `json:"cluster,omitempty"`
+	Kind         string `json:"kind"`
+	GVK          string `json:"gvk,omitempty"`
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	Text         string `json:"text"`
+	Match        string `json:"match"`
+	Pattern      string `json:"pattern,omitempty"`
+	Offset       int    `json:"offset"`
+	RuneOffset   int    `json:"runeOffset"`
+	Path         string `json:"path,omitempty"`
+	Node         string `json:"node,omitempty"`
+	App          string `json:"app,omitempty"`
+	Score        int    `json:"score,omitempty"`
+	MissingOwner string `json:"missingOwner,omitempty"`
+	DeletionTime string `json:"deletionTimestamp,omitempty"`
+	MoreMatches  int    `json:"moreMatches,omitempty"`
+	original     string
+	object       runtime.Object
+	gvk          schema.GroupVersionKind
+}
+
+// Score ranks object by number of matches found in it.
+func Score(matches []Match) int {
+	return len(matches)
 }
 
 func (a *Application) Validate() error {
-	if a.Kubeconfig == "" {
-		return errors.New("kubeconfig is required")
+	if a.isWhere(WhereLocal) && a.isWhere(WhereCluster) {
+		return errors.New("where can not be local and cluster at the same time")
 	}
 
-	if a.WhereToSearch == "" {
-		return errors.New("where-to-search is required")
+	if a.InCluster && a.KubeContext != "" {
+		return errors.New("in-cluster and context can not be used together")
 	}
 
-	if a.WhatToSearch == "" {
+	if a.AllNamespaces && a.Namespace != "" {
+		return errors.New("all-namespaces and namespace can not be used together")
+	}
+
+	if len(a.WhatToSearch) == 0 && !a.CountObjects && a.Port == 0 && a.References == "" {
 		return errors.New("what-to-search is required")
 	}
 
-	return nil
+	if slices.Contains(a.WhatToSearch, "") {
+		return errors.New("what-to-search must not be empty")
+	}
 
-}
+	// patterns are compiled again in Init, here they are only checked
+	// before connecting to cluster
+	if _, err := NewSearchEngine(a.patterns(), a.Except, a.RedactPattern, a.ShowTails, a.CaseSensitive); err != nil {
+		return errors.Wrap(err, "invalid pattern")
+	}
 
-func (a *Application) Init(ctx context.Context) error {
-	whatToSearchRe, err := regexp.Compile(a.WhatToSearch)
-	if err != nil {
-		return errors.Wrap(err, "error in regexp.Compile "+a.WhatToSearch)
+	if a.SkipTerminating && a.OnlyTerminating {
+		return errors.New("skip-terminating and only-terminating can not be used together")
+	}
+
+	if a.Port < 0 || a.Port > 65535 {
+		return errors.New("port must be in range 1-65535")
 	}
 
-	if a.Except != "" {
-		exceptRe, err := regexp.Compile(a.Except)
+	if a.SinceResourceVersion != "" {
+		sinceResourceVersion, err := strconv.ParseUint(a.SinceResourceVersion, 10, 64)
 		if err != nil {
-			return errors.Wrap(err, "error in regexp.Compile "+a.Except)
+			return errors.Wrap(err, "since-resource-version must be a number")
 		}
 
-		a.exceptRe = exceptRe
+		a.sinceResourceVersion = sinceResourceVersion
 	}
 
-	restconfig, err := clientcmd.BuildConfigFromFlags("", a.Kubeconfig)
-	if err != nil {
-		return errors.Wrap(err, "error in clientcmd.BuildConfigFromFlags")
+	if a.QPS <= 0 || a.Burst <= 0 {
+		return errors.New("qps and burst must be positive")
 	}
 
-	clientset, err := kubernetes.NewForConfig(restconfig)
+	if a.Workers <= 0 {
+		return errors.New("workers must be positive")
+	}
+
+	if a.MaxObjectSize < 0 {
+		return errors.New("max-object-size must not be negative")
+	}
+
+	if a.MaxMatches < 0 {
+		return errors.New("max-matches must not be negative")
+	}
+
+	if a.Retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+
+	if a.MaxTotalRetries < 0 {
+		return errors.New("max-total-retries must not be negative")
+	}
+
+	if a.Since < 0 {
+		return errors.New("since must not be negative")
+	}
+
+	if a.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+
+	if a.WatchDebounce < 0 {
+		return errors.New("watch-debounce must not be negative")
+	}
+
+	if a.References != "" {
+		kind, name, err := parseReference(a.References)
+		if err != nil {
+			return err
+		}
+
+		a.referenceKind, a.referenceName = kind, name
+	}
+
+	delimiter, err := parseDelimiter(a.Delimiter)
 	if err != nil {
-		return errors.Wrap(err, "error in kubernetes.NewForConfig")
+		return err
 	}
 
-	a.whatToSearchRe = whatToSearchRe
-	a.clientset = clientset
+	a.delimiter = delimiter
 
-	return nil
-}
+	if err := a.validateWatch(); err != nil {
+		return err
+	}
 
-func (a *Application) isInWhere(obj string) bool {
-	if a.WhereToSearch == "*" {
-		return true
+	if a.GVR != "" {
+		gvr, err := parseGVR(a.GVR)
+		if err != nil {
+			return err
+		}
+
+		a.gvr = &gvr
 	}
 
-	objs := strings.Split(strings.ToLower(a.WhereToSearch), ",")
+	if err := a.validateKinds(); err != nil {
+		return err
+	}
 
-	return slices.Contains(objs, strings.ToLower(obj))
-}
+	if a.ShowTails < 0 {
+		return errors.New("tails must not be negative")
+	}
 
-func (a *Application) removeUnnecessaryAnnotations(obj metav1.ObjectMeta) {
-	delete(obj.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
-}
+	if a.ContextLines < 0 {
+		return errors.New("context-lines must not be negative")
+	}
 
-func (a *Application) getPods(ctx context.Context) error {
-	const typeOf = "Pods"
+	if a.PageSize < 0 {
+		return errors.New("page-size must not be negative")
+	}
 
-	if !a.isInWhere(typeOf) {
-		return nil
+	if a.LimitPerKind < 0 {
+		return errors.New("limit-per-kind must not be negative")
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	if a.Sample <= 0 || a.Sample > 1 {
+		return errors.New("sample must be in range (0, 1]")
+	}
 
-	objects, err := a.clientset.CoreV1().Pods(a.Namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+	if a.ControllerPresets != "" {
+		for _, preset := range strings.Split(a.ControllerPresets, ",") {
+			if _, ok := controllerAnnotations[preset]; !ok {
+				return errors.Errorf("unknown controller-annotations preset %q, must be one of %s",
+					preset, strings.Join(slices.Sorted(maps.Keys(controllerAnnotations)), ", "))
+			}
+		}
 	}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+	if !slices.Contains(locatorFormats, a.LocatorFormat) {
+		return errors.Errorf("unknown locator-format %q, must be one of %s", a.LocatorFormat, strings.Join(locatorFormats, ", "))
+	}
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
-		})
+	if !slices.Contains(exceptFormats, a.ExceptFormat) {
+		return errors.Errorf("unknown except-format %q, must be one of %s", a.ExceptFormat, strings.Join(exceptFormats, ", "))
+	}
+
+	if !slices.Contains(colorModes, a.Color) {
+		return errors.Errorf("unknown color %q, must be one of %s", a.Color, strings.Join(colorModes, ", "))
+	}
+
+	if !slices.Contains(scopes, a.Scope) {
+		return errors.Errorf("unknown scope %q, must be one of %s", a.Scope, strings.Join(scopes, ", "))
+	}
+
+	if !slices.Contains(outputFormats, a.Output) {
+		return errors.Errorf("unknown output %q, must be one of %s", a.Output, strings.Join(outputFormats, ", "))
 	}
 
 	return nil
 }
 
-func (a *Application) getConfigmaps(ctx context.Context) error {
-	const typeOf = "ConfigMaps"
+// patterns returns patterns to search, with -word they match whole words.
+func (a *Application) patterns() []string {
+	if !a.Word {
+		return a.WhatToSearch
+	}
 
-	if !a.isInWhere(typeOf) {
-		return nil
+	patterns := make([]string, 0, len(a.WhatToSearch))
+
+	for _, pattern := range a.WhatToSearch {
+		patterns = append(patterns, wordPattern(pattern))
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	return patterns
+}
 
-	objects, err := a.clientset.CoreV1().ConfigMaps(a.Namespace).List(ctx, metav1.ListOptions{})
+func (a *Application) Init(ctx context.Context) error {
+	engine, err := NewSearchEngine(a.patterns(), a.Except, a.RedactPattern, a.ShowTails, a.CaseSensitive)
 	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+		return errors.Wrap(err, "error in NewSearchEngine")
 	}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+	engine.Squeeze = a.Squeeze
+	engine.ExceptFormat = a.ExceptFormat
+	engine.ContextLines = a.ContextLines
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
-		})
+	if a.AllNamespaces {
+		a.Namespace = metav1.NamespaceAll
+	}
+
+	if _, err := labels.Parse(a.Selector); err != nil {
+		return errors.Wrap(err, "error in labels.Parse")
+	}
+
+	if _, err := fields.ParseSelector(a.FieldSelector); err != nil {
+		return errors.Wrap(err, "error in fields.ParseSelector")
+	}
+
+	if a.ControllerPresets != "" {
+		for _, preset := range strings.Split(a.ControllerPresets, ",") {
+			a.controllerPrefixes = append(a.controllerPrefixes, controllerAnnotations[preset]...)
+		}
+	}
+
+	if a.Output == OutputTemplate {
+		if err := a.initTemplate(); err != nil {
+			return err
+		}
+	}
+
+	if a.OutputFile != "" {
+		a.outputFile, err = os.Create(a.OutputFile)
+		if err != nil {
+			return errors.Wrap(err, "error in os.Create")
+		}
+	}
+
+	if err := a.initClientset(); err != nil {
+		return err
 	}
 
+	if err := a.initClusterLabel(); err != nil {
+		return err
+	}
+
+	a.sampleSeed = a.SampleSeed
+	if a.sampleSeed == 0 {
+		a.sampleSeed = uint64(time.Now().UnixNano())
+	}
+
+	engine.Highlight = a.useColor()
+
+	// progress is for humans watching terminal, not for logs
+	a.progress = a.Progress && term.IsTerminal(int(os.Stderr.Fd()))
+	a.engine = engine
+
 	return nil
 }
 
-func (a *Application) getDeployments(ctx context.Context) error {
-	const typeOf = "Deployments"
+func (a *Application) initClientset() error {
+	// clientset set before Init is kept, tests use fake clientset,
+	// custom resources and watch need dynamic client set with it
+	if a.clientset != nil {
+		if a.dynamicClient == nil && (a.gvr != nil || a.Watch) {
+			return errors.New("dynamic client is required with clientset for gvr and watch")
+		}
 
-	if !a.isInWhere(typeOf) {
 		return nil
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	if a.FakeFromDir != "" {
+		objects, err := loadManifests(a.FakeFromDir)
+		if err != nil {
+			return errors.Wrap(err, "error in loadManifests")
+		}
 
-	objects, err := a.clientset.AppsV1().Deployments(a.Namespace).List(ctx, metav1.ListOptions{})
+		if err := a.initFakeClientset(objects); err != nil {
+			return err
+		}
+
+		a.clusterHost = "fake://" + a.FakeFromDir
+		a.clusterContext = "fake"
+
+		return nil
+	}
+
+	// local manifests are searched with the same getters from fake cluster
+	if a.isWhere(WhereLocal) {
+		objects, err := a.localManifests()
+		if err != nil {
+			return err
+		}
+
+		if err := a.initFakeClientset(objects); err != nil {
+			return err
+		}
+
+		a.clusterHost = "file://" + a.Path
+		a.clusterContext = WhereLocal
+
+		return nil
+	}
+
+	restconfig, clusterContext, err := a.restConfig()
 	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+		return err
 	}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+	restconfig.QPS = float32(a.QPS)
+	restconfig.Burst = a.Burst
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
-		})
+	clientset, err := kubernetes.NewForConfig(restconfig)
+	if err != nil {
+		return errors.Wrap(err, "error in kubernetes.NewForConfig")
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(restconfig)
+	if err != nil {
+		return errors.Wrap(err, "error in dynamic.NewForConfig")
+	}
+
+	a.clientset = clientset
+	a.dynamicClient = dynamicClient
+	a.clusterHost = restconfig.Host
+	a.clusterContext = clusterContext
+
 	return nil
 }
 
-func (a *Application) getStatefulSets(ctx context.Context) error {
-	const typeOf = "StatefulSets"
+// initFakeClientset serves objects from fake clients, custom resources
+// are served only by dynamic client.
+func (a *Application) initFakeClientset(objects []runtime.Object) error {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	if a.gvr != nil {
+		listKinds[*a.gvr] = "List"
+	}
 
-	if !a.isInWhere(typeOf) {
-		return nil
+	clientset := fake.NewSimpleClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme.Scheme, listKinds)
+
+	for _, object := range uniqueObjects(objects) {
+		if _, ok := object.(*unstructured.Unstructured); !ok {
+			if err := clientset.Tracker().Add(object); err != nil {
+				return errors.Wrap(err, "error in adding object to fake clientset")
+			}
+		}
+
+		if err := dynamicClient.Tracker().Add(object); err != nil {
+			return errors.Wrap(err, "error in adding object to fake dynamic client")
+		}
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	a.clientset = clientset
+	a.dynamicClient = dynamicClient
+
+	return nil
+}
 
-	objects, err := a.clientset.AppsV1().StatefulSets(a.Namespace).List(ctx, metav1.ListOptions{})
+type clusterLabelData struct {
+	Context string
+	Host    string
+}
+
+func (a *Application) initClusterLabel() error {
+	if a.ClusterLabel == "" {
+		return nil
+	}
+
+	tmpl, err := template.New("cluster-label").Parse(a.ClusterLabel)
 	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+		return errors.Wrap(err, "error in template.Parse")
 	}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+	var label strings.Builder
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
-		})
+	err = tmpl.Execute(&label, clusterLabelData{
+		Context: a.clusterContext,
+		Host:    a.clusterHost,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error in template.Execute")
 	}
 
+	a.cluster = label.String()
+
 	return nil
 }
 
+// listEntries returns lowercased entries of comma separated list.
+func listEntries(value string) []string {
+	entries := strings.Split(strings.ToLower(value), ",")
+
+	for i := range entries {
+		entries[i] = strings.TrimSpace(entries[i])
+	}
+
+	return entries
+}
+
+// whereEntries returns lowercased entries of -where.
+func (a *Application) whereEntries() []string {
+	return listEntries(a.WhereToSearch)
+}
+
+// isWhere reports whether -where has entry, it is used for local and cluster sources.
+func (a *Application) isWhere(entry string) bool {
+	return slices.Contains(a.whereEntries(), entry)
+}
+
+func (a *Application) isInWhere(obj string) bool {
+	objs := slices.DeleteFunc(a.whereEntries(), func(entry string) bool {
+		return entry == "" || entry == WhereLocal || entry == WhereCluster
+	})
+
+	if len(objs) == 0 || slices.Contains(objs, "*") {
+		return true
+	}
+
+	return slices.ContainsFunc(objs, func(entry string) bool {
+		return resolveKind(entry) == strings.ToLower(obj)
+	})
+}
+
+// isExceptKind reports whether kind is excluded with -except-kind.
+func (a *Application) isExceptKind(obj string) bool {
+	return slices.ContainsFunc(listEntries(a.ExceptKind), func(entry string) bool {
+		return resolveKind(entry) == strings.ToLower(obj)
+	})
+}
+
+func (a *Application) getPods(ctx context.Context) error {
+	const typeOf = "Pods"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		if a.Node != "" {
+			node := fields.OneTermEqualSelector("spec.nodeName", a.Node).String()
+
+			if opts.FieldSelector != "" {
+				node = opts.FieldSelector + "," + node
+			}
+
+			opts.FieldSelector = node
+		}
+
+		return a.clientset.CoreV1().Pods(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getConfigmaps(ctx context.Context) error {
+	const typeOf = "ConfigMaps"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getSecrets(ctx context.Context) error {
+	const typeOf = "Secrets"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().Secrets(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getDeployments(ctx context.Context) error {
+	const typeOf = "Deployments"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.AppsV1().Deployments(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getStatefulSets(ctx context.Context) error {
+	const typeOf = "StatefulSets"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getDaemonSets(ctx context.Context) error {
+	const typeOf = "DaemonSets"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.AppsV1().DaemonSets(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getReplicaSets(ctx context.Context) error {
+	const typeOf = "ReplicaSets"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getJobs(ctx context.Context) error {
+	const typeOf = "Jobs"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.BatchV1().Jobs(namespace).List(ctx, opts)
+	})
+}
+
 func (a *Application) getCronJobs(ctx context.Context) error {
 	const typeOf = "CronJobs"
 
-	if !a.isInWhere(typeOf) {
-		return nil
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) search(ctx context.Context) error {
+	a.searchedObjects = len(a.KubernetesObjects)
+
+	// objects are searched by workers, matches are kept in order of objects
+	// so output does not depend on number of workers
+	results := make([][]Match, len(a.KubernetesObjects))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(a.Workers)
+
+	for i, obj := range a.KubernetesObjects {
+		group.Go(func() error {
+			matches, err := a.searchObject(groupCtx, obj)
+			if err != nil {
+				return err
+			}
+
+			results[i] = matches
+
+			return nil
+		})
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	if err := group.Wait(); err != nil {
+		return err
+	}
 
-	objects, err := a.clientset.BatchV1().CronJobs(a.Namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+	for _, matches := range results {
+		a.Matches = append(a.Matches, matches...)
 	}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+	if a.Sort {
+		slices.SortStableFunc(a.Matches, func(x, y Match) int {
+			return cmp.Or(
+				strings.Compare(x.Kind, y.Kind),
+				strings.Compare(x.Namespace, y.Namespace),
+				strings.Compare(x.Name, y.Name),
+			)
+		})
+	}
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
+	if a.Rank {
+		slices.SortStableFunc(a.Matches, func(x, y Match) int {
+			return y.Score - x.Score
 		})
 	}
 
 	return nil
 }
 
-func (a *Application) search() {
-	for _, obj := range a.KubernetesObjects {
-		slog := slog.With(
+// searchObject returns matches in one object, object that can not be
+// searched because of unexpected content is skipped.
+func (a *Application) searchObject(ctx context.Context, obj KubernetesObject) (matches []Match, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("can not search object, skipping",
+				"kind", obj.Kind,
+				"name", obj.Name,
+				"namespace", obj.Namespace,
+				"panic", r,
+			)
+
+			matches, err = nil, nil
+		}
+	}()
+
+	if a.engine.Excluded(obj.Kind, obj.Name, obj.Namespace) {
+		slog.Debug("ignored",
 			"kind", obj.Kind,
 			"name", obj.Name,
 			"namespace", obj.Namespace,
 		)
 
-		if a.exceptRe != nil && a.exceptRe.MatchString(obj.Namespace+"/"+obj.Name) {
-			slog.Debug("ignored")
-			continue
+		return nil, nil
+	}
+
+	if a.MaxObjectSize > 0 && len(obj.Object) > a.MaxObjectSize {
+		slog.Warn("object is too big, it is not searched",
+			"kind", obj.Kind,
+			"name", obj.Name,
+			"namespace", obj.Namespace,
+			"size", len(obj.Object),
+		)
+
+		return nil, nil
+	}
+
+	if a.LatestOnly {
+		stale, err := a.isStaleRevision(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if stale {
+			return nil, nil
+		}
+	}
+
+	fields, err := a.fields(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case a.Port > 0:
+		matches = a.searchValue(obj, fields, strconv.Itoa(a.Port))
+	case a.References != "":
+		matches = a.searchValue(obj, fields, a.referenceName)
+	default:
+		matches = a.searchFields(obj, fields)
+	}
+
+	// matches in whole text of object get path of field they are in
+	if len(fields) == 1 && len(fields[0].Path) == 0 {
+		a.locateMatches(obj, matches)
+	}
+
+	if a.Invert {
+		matches = a.invertMatches(obj, matches)
+	}
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	if a.Orphans {
+		ref, err := a.missingOwner(ctx, obj)
+		if err != nil {
+			return nil, err
 		}
 
-		locs := a.whatToSearchRe.FindAllStringIndex(strings.ToLower(obj.Object), -1)
+		if ref == nil {
+			return nil, nil
+		}
+
+		for i := range matches {
+			matches[i].MissingOwner = ref.Kind + "/" + ref.Name
+		}
+	}
+
+	if a.Rank {
+		score := Score(matches)
+
+		for i := range matches {
+			matches[i].Score = score
+		}
+	}
+
+	// last printed match tells how many matches are not printed
+	if a.MaxMatches > 0 && len(matches) > a.MaxMatches {
+		matches[a.MaxMatches-1].MoreMatches = len(matches) - a.MaxMatches
+		matches = matches[:a.MaxMatches]
+	}
+
+	return matches, nil
+}
+
+// locateMatches sets path of field at offset of every match, matches
+// whose field can not be found in text get path of first field that
+// contains matched text, matches across several fields are left without path.
+func (a *Application) locateMatches(obj KubernetesObject, matches []Match) {
+	if len(matches) == 0 {
+		return
+	}
+
+	leafs, err := leafFields(obj.Raw)
+	if err != nil {
+		slog.Debug("can not locate matches", "kind", obj.Kind, "name", obj.Name, "error", err)
+
+		return
+	}
+
+	spans := leafSpans(obj.Object, leafs)
+
+	for i := range matches {
+		if path, ok := spanPath(spans, matches[i].Offset, len(matches[i].original)); ok {
+			matches[i].Path = path.format(a.LocatorFormat)
 
-		if locs == nil {
 			continue
 		}
 
-		for _, loc := range locs {
-			start := loc[0] - a.ShowTails
-			end := loc[1] + a.ShowTails
+		for _, leaf := range leafs {
+			if a.engine.Contains(leaf.Value, matches[i].original) {
+				matches[i].Path = leaf.Path.format(a.LocatorFormat)
 
-			if start < 0 {
-				start = 0
+				break
 			}
+		}
+	}
+}
+
+// leafSpan is byte range of leaf value in text of object.
+type leafSpan struct {
+	start int
+	end   int
+	path  fieldPath
+}
+
+// leafSpans finds leafs in YAML text of object, leafs are in the same
+// order as YAML keys. Values that are not written on line of their key,
+// like multiline strings, are skipped.
+func leafSpans(text string, leafs []field) []leafSpan {
+	spans := make([]leafSpan, 0, len(leafs))
+	cursor := 0
+
+	for _, leaf := range leafs {
+		if leaf.Value == "" || len(leaf.Path) == 0 {
+			continue
+		}
+
+		start := -1
 
-			if max := len(obj.Object); end > max {
-				end = max
+		switch elem := leaf.Path[len(leaf.Path)-1].(type) {
+		case string:
+			key := strings.Index(text[cursor:], elem+": ")
+			if key < 0 {
+				continue
 			}
 
-			text := obj.Object[start:end]
-			text = strings.ReplaceAll(text, "\n", " ")
+			from := cursor + key + len(elem) + 2
+			line, _, _ := strings.Cut(text[from:], "\n")
 
-			slog.Info(text)
+			if i := strings.Index(line, leaf.Value); i >= 0 {
+				start = from + i
+			}
+		case int:
+			if i := strings.Index(text[cursor:], "- "+leaf.Value); i >= 0 {
+				start = cursor + i + 2
+			}
 		}
+
+		if start < 0 {
+			continue
+		}
+
+		cursor = start + len(leaf.Value)
+		spans = append(spans, leafSpan{start: start, end: cursor, path: leaf.Path})
 	}
+
+	return spans
 }
 
-func (a *Application) getIngress(ctx context.Context) error {
-	const typeOf = "Ingress"
+// spanPath returns path of leaf that holds whole match at offset.
+func spanPath(spans []leafSpan, offset, length int) (fieldPath, bool) {
+	for _, span := range spans {
+		if offset >= span.start && offset+length <= span.end {
+			return span.path, true
+		}
+	}
+
+	return nil, false
+}
 
-	if !a.isInWhere(typeOf) {
+// invertMatches reports object without snippet when it has no matches with -invert.
+func (a *Application) invertMatches(obj KubernetesObject, matches []Match) []Match {
+	if len(matches) > 0 {
 		return nil
 	}
 
-	slog.Info("Getting " + typeOf + " ...")
+	return []Match{a.objectMatch(obj, field{}, Match{
+		Name:      obj.Name,
+		Namespace: obj.Namespace,
+	})}
+}
 
-	objects, err := a.clientset.NetworkingV1().Ingresses(a.Namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return errors.Wrap(err, "error in "+typeOf)
+// fields returns values of object to match depending on search mode.
+func (a *Application) fields(obj KubernetesObject) ([]field, error) {
+	switch {
+	case a.Port > 0:
+		return portFields(obj.Raw), nil
+	case a.References != "":
+		return referenceFields(obj.Raw, a.referenceKind), nil
+	case a.FindVolume:
+		return volumeFields(obj.Raw), nil
+	case a.FindPullSecret:
+		return pullSecretFields(obj.Raw), nil
+	case len(a.controllerPrefixes) > 0:
+		return annotationFields(obj.Raw, a.controllerPrefixes)
+	case a.ManagedBy:
+		return managerFields(obj.Raw)
+	case a.FindFinalizer:
+		return finalizerFields(obj.Raw)
+	case a.Scope == ScopeMetadata:
+		return metadataFields(obj), nil
+	case a.Scope == ScopeImages:
+		return imageFields(obj.Raw), nil
+	case a.Scope == ScopeEnv:
+		return envFields(obj.Raw), nil
+	case a.Precise:
+		fields, err := leafFields(obj.Raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "error in leafFields")
+		}
+
+		return fields, nil
+	default:
+		return []field{{Value: obj.Object}}, nil
 	}
+}
 
-	for _, object := range objects.Items {
-		a.removeUnnecessaryAnnotations(object.ObjectMeta)
+func (a *Application) searchFields(obj KubernetesObject, fields []field) []Match {
+	matches := make([]Match, 0)
 
-		a.KubernetesObjects = append(a.KubernetesObjects, KubernetesObject{
-			Kind:      typeOf,
-			Name:      object.Name,
-			Namespace: object.Namespace,
-			Object:    object.String(),
-		})
+	for _, f := range fields {
+		for _, match := range a.engine.Search(obj.Name, obj.Namespace, f.Value) {
+			matches = append(matches, a.objectMatch(obj, f, match))
+		}
 	}
 
-	return nil
+	return matches
+}
+
+// searchValue compares fields with value exactly, it is used
+// for -port and -references instead of patterns.
+func (a *Application) searchValue(obj KubernetesObject, fields []field, value string) []Match {
+	matches := make([]Match, 0)
+
+	for _, f := range fields {
+		if f.Value != value {
+			continue
+		}
+
+		matches = append(matches, a.objectMatch(obj, f, Match{
+			Name:      obj.Name,
+			Namespace: obj.Namespace,
+			Text:      f.Value,
+			Match:     f.Value,
+		}))
+	}
+
+	return matches
+}
+
+// objectMatch fills match with details of object and field.
+func (a *Application) objectMatch(obj KubernetesObject, f field, match Match) Match {
+	match.Cluster = a.cluster
+	match.Kind = obj.Kind
+	match.object, match.gvk = obj.Raw, obj.GVK
+
+	if !obj.GVK.Empty() {
+		match.GVK = qualifiedKind(obj.GVK)
+	}
+	match.Node = obj.Node
+
+	if a.GroupByApp {
+		match.App = a.appName(obj)
+	}
+
+	if obj.DeletionTime != nil {
+		match.DeletionTime = obj.DeletionTime.UTC().Format(time.RFC3339)
+	}
+
+	if len(f.Path) > 0 {
+		match.Path = f.Path.format(a.LocatorFormat)
+	}
+
+	return match
+}
+
+// qualifiedKind formats kind with its api version like apps/v1 Deployment.
+func qualifiedKind(gvk schema.GroupVersionKind) string {
+	return gvk.GroupVersion().String() + " " + gvk.Kind
+}
+
+func (a *Application) appName(obj KubernetesObject) string {
+	if app := obj.Labels[a.AppLabel]; app != "" {
+		return app
+	}
+
+	return unlabeledApp
+}
+
+func (a *Application) getServices(ctx context.Context) error {
+	const typeOf = "Services"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().Services(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getIngresses(ctx context.Context) error {
+	const typeOf = "Ingresses"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.NetworkingV1().Ingresses(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getEndpointSlices(ctx context.Context) error {
+	const typeOf = "EndpointSlices"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getNetworkPolicies(ctx context.Context) error {
+	const typeOf = "NetworkPolicies"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.NetworkingV1().NetworkPolicies(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getPersistentVolumeClaims(ctx context.Context) error {
+	const typeOf = "PersistentVolumeClaims"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getPersistentVolumes(ctx context.Context) error {
+	const typeOf = "PersistentVolumes"
+
+	return a.listClusterScoped(ctx, typeOf, func(ctx context.Context, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().PersistentVolumes().List(ctx, opts)
+	})
+}
+
+func (a *Application) getHPAs(ctx context.Context) error {
+	const typeOf = "HorizontalPodAutoscalers"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getPDBs(ctx context.Context) error {
+	const typeOf = "PodDisruptionBudgets"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getServiceAccounts(ctx context.Context) error {
+	const typeOf = "ServiceAccounts"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
+	})
+}
+
+// getEvents lists events page by page like other kinds,
+// use -limit-per-kind to bound busy clusters.
+func (a *Application) getEvents(ctx context.Context) error {
+	const typeOf = "Events"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().Events(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getRoles(ctx context.Context) error {
+	const typeOf = "Roles"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.RbacV1().Roles(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getRoleBindings(ctx context.Context) error {
+	const typeOf = "RoleBindings"
+
+	return a.list(ctx, typeOf, func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.RbacV1().RoleBindings(namespace).List(ctx, opts)
+	})
+}
+
+func (a *Application) getClusterRoles(ctx context.Context) error {
+	const typeOf = "ClusterRoles"
+
+	return a.listClusterScoped(ctx, typeOf, func(ctx context.Context, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.RbacV1().ClusterRoles().List(ctx, opts)
+	})
+}
+
+func (a *Application) getClusterRoleBindings(ctx context.Context) error {
+	const typeOf = "ClusterRoleBindings"
+
+	return a.listClusterScoped(ctx, typeOf, func(ctx context.Context, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	})
+}
+
+func (a *Application) getNamespaces(ctx context.Context) error {
+	const typeOf = "Namespaces"
+
+	return a.listClusterScoped(ctx, typeOf, func(ctx context.Context, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().Namespaces().List(ctx, opts)
+	})
+}
+
+func (a *Application) getComponentStatuses(ctx context.Context) error {
+	const typeOf = "ComponentStatuses"
+
+	return a.listClusterScoped(ctx, typeOf, func(ctx context.Context, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return a.clientset.CoreV1().ComponentStatuses().List(ctx, opts)
+	})
 }
 
 type searchFunc func(context.Context) error
 
-func (a *Application) Run(ctx context.Context) error {
+// ExitCode returns exit code like grep, 1 when search found nothing.
+func (a *Application) ExitCode() int {
+	if len(a.Matches) == 0 && !a.CountObjects && !a.DryRun {
+		return 1
+	}
+
+	return 0
+}
+
+// FindMatches fetches objects and returns all matches without printing them,
+// application must be validated and initialized before. When ctx is done
+// during fetch, matches of objects fetched so far are returned with error.
+// Every call searches again, results of previous call are dropped.
+func (a *Application) FindMatches(ctx context.Context) ([]Match, error) {
+	a.listed = nil
+	a.KubernetesObjects = nil
+	a.KindStats = nil
+	a.Matches = nil
+
+	fetchErr := a.fetch(ctx)
+	if fetchErr != nil && ctx.Err() == nil {
+		return nil, fetchErr
+	}
+
+	searchCtx := ctx
+	if fetchErr != nil {
+		searchCtx = context.WithoutCancel(ctx)
+	}
+
+	if err := a.search(searchCtx); err != nil {
+		return nil, err
+	}
+
+	return a.Matches, fetchErr
+}
+
+// fetch runs getters of all kinds concurrently, list guards shared state with mutex.
+func (a *Application) fetch(ctx context.Context) error {
 	searchFuncs := []searchFunc{
 		a.getPods,
 		a.getConfigmaps,
+		a.getSecrets,
 		a.getDeployments,
 		a.getStatefulSets,
+		a.getDaemonSets,
+		a.getReplicaSets,
+		a.getJobs,
 		a.getCronJobs,
-		a.getIngress,
+		a.getServices,
+		a.getIngresses,
+		a.getEndpointSlices,
+		a.getNetworkPolicies,
+		a.getPersistentVolumeClaims,
+		a.getPersistentVolumes,
+		a.getHPAs,
+		a.getPDBs,
+		a.getServiceAccounts,
+		a.getEvents,
+		a.getRoles,
+		a.getRoleBindings,
+		a.getClusterRoles,
+		a.getClusterRoleBindings,
+		a.getNamespaces,
+		a.getComponentStatuses,
+		a.getCustomResources,
 	}
 
+	group, groupCtx := errgroup.WithContext(ctx)
+
 	for _, f := range searchFuncs {
-		if err := f(ctx); err != nil {
+		group.Go(func() error {
+			return f(groupCtx)
+		})
+	}
+
+	err := group.Wait()
+
+	// kinds are fetched in any order, sort them for stable output
+	slices.SortStableFunc(a.KubernetesObjects, func(x, y KubernetesObject) int {
+		return strings.Compare(x.Kind, y.Kind)
+	})
+	slices.SortFunc(a.KindStats, func(x, y *KindStat) int {
+		return strings.Compare(x.Kind, y.Kind)
+	})
+
+	return err
+}
+
+func (a *Application) Run(ctx context.Context) error {
+	a.started = time.Now()
+
+	if a.DryRun {
+		return a.printDryRun(os.Stdout)
+	}
+
+	if a.CountObjects {
+		if err := a.fetch(ctx); err != nil {
 			return err
 		}
+
+		a.printObjectsCount()
+
+		return nil
 	}
 
-	a.search()
+	defer a.printKindStats()
+
+	out := io.Writer(os.Stdout)
+
+	if a.outputFile != nil {
+		defer a.outputFile.Close()
+
+		out = a.outputFile
+	}
+
+	writer := a.resultWriter(out)
+
+	if a.isBuffered() {
+		_, findErr := a.FindMatches(ctx)
+
+		// interrupted search prints matches of objects fetched before
+		if findErr != nil && ctx.Err() == nil {
+			return findErr
+		}
+
+		if err := a.printMatches(writer); err != nil {
+			return err
+		}
+
+		if findErr != nil {
+			return findErr
+		}
+	} else if err := a.stream(ctx, writer); err != nil {
+		return err
+	}
+
+	if a.Watch {
+		if err := a.watch(ctx, writer); err != nil {
+			return errors.Wrap(err, "error in watch")
+		}
+	}
+
+	if a.outputFile != nil {
+		if err := a.outputFile.Close(); err != nil {
+			return errors.Wrap(err, "error in closing output-file")
+		}
+	}
+
+	if a.SinceResourceVersion != "" {
+		slog.Info("to search only objects changed after this run use",
+			"since-resource-version", a.latestResourceVersion,
+		)
+	}
+
+	if a.Webhook != "" {
+		if err := a.sendWebhook(ctx); err != nil {
+			return errors.Wrap(err, "error in sendWebhook")
+		}
+	}
 
 	return nil
 }
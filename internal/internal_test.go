@@ -0,0 +1,1200 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestApplication returns application searching pattern in objects
+// served by fake clientset.
+func newTestApplication(pattern string, objects ...runtime.Object) *Application {
+	a := NewApplication()
+	a.WhatToSearch = []string{pattern}
+	a.SampleSeed = 1
+	a.ResultWriter = DiscardWriter{}
+	a.clientset = fake.NewSimpleClientset(objects...)
+
+	return a
+}
+
+// findMatches validates and initializes application and returns its matches.
+func findMatches(t *testing.T, a *Application) []Match {
+	t.Helper()
+
+	initApplication(t, a)
+
+	matches, err := a.FindMatches(t.Context())
+	if err != nil {
+		t.Fatalf("FindMatches: %v", err)
+	}
+
+	return matches
+}
+
+// matchedObjects returns kind/namespace/name of every match.
+func matchedObjects(matches []Match) []string {
+	objects := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		objects = append(objects, match.Kind+"/"+match.Namespace+"/"+match.Name)
+	}
+
+	return objects
+}
+
+// recordWriter keeps written matches.
+type recordWriter struct {
+	matches []Match
+}
+
+func (w *recordWriter) Write(match Match) error {
+	w.matches = append(w.matches, match)
+
+	return nil
+}
+
+func (w *recordWriter) Flush() error {
+	return nil
+}
+
+// initApplication validates and initializes application.
+func initApplication(t testing.TB, a *Application) {
+	t.Helper()
+
+	if err := a.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if err := a.Init(t.Context()); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+}
+
+// listedResources records resources listed by application and
+// returns function reporting them sorted.
+func listedResources(a *Application) func() []string {
+	var (
+		mu        sync.Mutex
+		resources []string
+	)
+
+	a.clientset.(*fake.Clientset).PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if resource := action.GetResource().Resource; !slices.Contains(resources, resource) {
+			resources = append(resources, resource)
+		}
+
+		return false, nil, nil
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return slices.Sorted(slices.Values(resources))
+	}
+}
+
+func testPod(namespace, name, image string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: image}},
+		},
+	}
+}
+
+func testConfigMap(namespace, name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       data,
+	}
+}
+
+func TestGetPods(t *testing.T) {
+	a := newTestApplication("x",
+		testPod("prod", "web", "nginx"),
+		testPod("dev", "db", "postgres"),
+	)
+	a.WhereToSearch = "pods"
+
+	if err := a.Init(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.getPods(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.KubernetesObjects) != 2 {
+		t.Fatalf("expected 2 pods, got %d", len(a.KubernetesObjects))
+	}
+
+	for _, obj := range a.KubernetesObjects {
+		if obj.Kind != "Pods" {
+			t.Errorf("expected kind Pods, got %s", obj.Kind)
+		}
+	}
+}
+
+func TestGetConfigmaps(t *testing.T) {
+	a := newTestApplication("x",
+		testConfigMap("prod", "cfg", map[string]string{"db": "postgres://db"}),
+		testPod("prod", "web", "nginx"),
+	)
+	a.Namespace = "prod"
+
+	if err := a.Init(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.getConfigmaps(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.KubernetesObjects) != 1 {
+		t.Fatalf("expected 1 configmap, got %d", len(a.KubernetesObjects))
+	}
+
+	if obj := a.KubernetesObjects[0]; obj.Kind != "ConfigMaps" || obj.Name != "cfg" || obj.Namespace != "prod" {
+		t.Errorf("unexpected object %s %s/%s", obj.Kind, obj.Namespace, obj.Name)
+	}
+}
+
+func TestLocateMatches(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "prod",
+			Labels:    map[string]string{"app": "nginx"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "nginx:1.25"}},
+				},
+			},
+		},
+	}
+
+	a := newTestApplication("nginx", deployment)
+	a.WhereToSearch = "deployments"
+
+	paths := make([]string, 0)
+	for _, match := range findMatches(t, a) {
+		paths = append(paths, match.Path)
+	}
+
+	want := []string{"metadata.labels.app", "spec.template.spec.containers[0].image"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("expected paths %v, got %v", want, paths)
+	}
+}
+
+func TestBufferedInterrupted(t *testing.T) {
+	a := newTestApplication("postgres")
+	a.WhereToSearch = "configmaps"
+	a.Namespace = "prod"
+	a.Buffered = true
+	a.PageSize = 1
+
+	writer := &recordWriter{}
+	a.ResultWriter = writer
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// interrupt comes after first page is fetched, while second is listed
+	a.clientset.(*fake.Clientset).PrependReactor("list", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.ListActionImpl).ListOptions.Continue == "" {
+			return true, &corev1.ConfigMapList{
+				ListMeta: metav1.ListMeta{Continue: "page-2"},
+				Items:    []corev1.ConfigMap{*testConfigMap("prod", "cfg", map[string]string{"url": "postgres://db"})},
+			}, nil
+		}
+
+		cancel()
+
+		return true, nil, ctx.Err()
+	})
+
+	initApplication(t, a)
+
+	if err := a.Run(ctx); err == nil {
+		t.Fatal("expected error of interrupted search")
+	}
+
+	if got := matchedObjects(writer.matches); !slices.Equal(got, []string{"ConfigMaps/prod/cfg"}) {
+		t.Errorf("expected matches of fetched objects to be printed, got %v", got)
+	}
+}
+
+func TestFindMatches(t *testing.T) {
+	a := newTestApplication("postgres",
+		testConfigMap("prod", "cfg", map[string]string{"url": "postgres://db"}),
+		testPod("prod", "db", "postgres:16"),
+		testPod("prod", "web", "nginx"),
+	)
+	a.Namespace = "prod"
+
+	matches := findMatches(t, a)
+
+	want := []string{"ConfigMaps/prod/cfg", "Pods/prod/db"}
+	if got := slices.Compact(matchedObjects(matches)); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, match := range matches {
+		if match.Match != "postgres" || match.Offset <= 0 || !strings.Contains(match.Text, "postgres") {
+			t.Errorf("unexpected match %+v", match)
+		}
+	}
+
+	// second call searches again instead of appending to previous results
+	again, err := a.FindMatches(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(again) != len(matches) || len(a.KubernetesObjects) != 3 {
+		t.Errorf("expected %d matches of 3 objects, got %d matches of %d objects", len(matches), len(again), len(a.KubernetesObjects))
+	}
+}
+
+func TestWhereToSearch(t *testing.T) {
+	all := []string{
+		"clusterrolebindings", "clusterroles", "componentstatuses", "configmaps", "cronjobs",
+		"daemonsets", "deployments", "endpointslices", "events", "horizontalpodautoscalers",
+		"ingresses", "jobs", "namespaces", "networkpolicies", "persistentvolumeclaims",
+		"persistentvolumes", "poddisruptionbudgets", "pods", "replicasets", "rolebindings",
+		"roles", "secrets", "serviceaccounts", "services", "statefulsets",
+	}
+
+	tests := []struct {
+		where string
+		want  []string
+	}{
+		{"*", all},
+		{"", all},
+		{"pods,deployments", []string{"deployments", "pods"}},
+		{"po, deploy", []string{"deployments", "pods"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("x")
+		a.WhereToSearch = tt.where
+		listed := listedResources(a)
+
+		findMatches(t, a)
+
+		if got := listed(); !slices.Equal(got, tt.want) {
+			t.Errorf("where %q: expected %v, got %v", tt.where, tt.want, got)
+		}
+	}
+}
+
+func TestServicesAndIngresses(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "prod",
+			Annotations: map[string]string{"external-dns.alpha.kubernetes.io/hostname": "shop.example.com"},
+		},
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "shop.example.com"}},
+		},
+	}
+
+	other := service.DeepCopy()
+	other.Namespace = "dev"
+
+	a := newTestApplication("shop.example.com", service, ingress, other)
+	a.Namespace = "prod"
+
+	got := slices.Compact(matchedObjects(findMatches(t, a)))
+	if want := []string{"Ingresses/prod/web", "Services/prod/web"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGetDaemonSetsAndReplicaSets(t *testing.T) {
+	objects := []runtime.Object{
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "prod"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "prod"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev"}},
+	}
+
+	tests := []struct {
+		where string
+		want  []string
+	}{
+		{"", []string{"DaemonSets/prod/agent", "ReplicaSets/prod/web-1"}},
+		{"daemonsets", []string{"DaemonSets/prod/agent"}},
+		{"replicasets", []string{"ReplicaSets/prod/web-1"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("x", objects...)
+		a.WhereToSearch = tt.where
+		a.Namespace = "prod"
+
+		if err := a.Init(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := a.getDaemonSets(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := a.getReplicaSets(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]string, 0)
+		for _, obj := range a.KubernetesObjects {
+			got = append(got, obj.Kind+"/"+obj.Namespace+"/"+obj.Name)
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("where %q: expected %v, got %v", tt.where, tt.want, got)
+		}
+	}
+}
+
+func TestJobsAndCronJobs(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "migrate", Image: "registry.example.com/removed:1.0"}},
+		},
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "prod"},
+		Spec:       batchv1.JobSpec{Template: template},
+	}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "prod"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}},
+		},
+	}
+
+	a := newTestApplication("removed:1.0", job, cronJob, testPod("prod", "web", "registry.example.com/removed:1.0"))
+	a.WhereToSearch = "jobs,cronjobs"
+
+	got := slices.Compact(matchedObjects(findMatches(t, a)))
+	if want := []string{"CronJobs/prod/nightly", "Jobs/prod/migrate"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestAllNamespaces(t *testing.T) {
+	a := newTestApplication("x")
+	a.AllNamespaces = true
+	a.Namespace = "prod"
+
+	if err := a.Validate(); err == nil {
+		t.Error("expected all-namespaces and namespace to conflict")
+	}
+
+	a = newTestApplication("nginx", testPod("prod", "web", "nginx"), testPod("dev", "web", "nginx"))
+	a.AllNamespaces = true
+	a.WhereToSearch = "pods"
+
+	if got := matchedObjects(findMatches(t, a)); len(got) != 2 {
+		t.Errorf("expected pods of all namespaces, got %v", got)
+	}
+}
+
+func TestFetchConcurrently(t *testing.T) {
+	const delay = 50 * time.Millisecond
+
+	var (
+		mu                  sync.Mutex
+		inFlight, maxFlight int
+	)
+
+	// fake clientset runs reactors one by one, so lists are served by API server stub
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxFlight = max(maxFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(delay)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"metadata": {}, "items": []}`))
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL, QPS: 1000, Burst: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newTestApplication("nginx")
+	a.clientset = clientset
+	initApplication(t, a)
+
+	started := time.Now()
+
+	if _, err := a.FindMatches(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 25 kinds listed one by one take more than a second
+	if elapsed := time.Since(started); elapsed > 10*delay {
+		t.Errorf("expected kinds to be fetched concurrently, took %s", elapsed)
+	}
+
+	if maxFlight < 2 {
+		t.Errorf("expected concurrent list requests, got at most %d", maxFlight)
+	}
+
+	if len(a.KindStats) != 25 {
+		t.Errorf("expected all 25 kinds to be fetched, got %d", len(a.KindStats))
+	}
+
+	for _, stat := range a.KindStats {
+		if len(stat.Errs) > 0 {
+			t.Errorf("%s: %v", stat.Kind, stat.Errs)
+		}
+	}
+}
+
+func TestFetchResultsStable(t *testing.T) {
+	objects := []runtime.Object{
+		testPod("prod", "web", "nginx"),
+		testPod("dev", "web", "nginx"),
+		testConfigMap("prod", "cfg", map[string]string{"image": "nginx"}),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "prod"}},
+	}
+
+	var previous []string
+
+	for range 5 {
+		got := matchedObjects(findMatches(t, newTestApplication("nginx", objects...)))
+
+		if previous != nil && !slices.Equal(got, previous) {
+			t.Fatalf("expected the same matches in every run, got %v and %v", previous, got)
+		}
+
+		previous = got
+	}
+}
+
+func TestSnippetAfterNonASCIIUppercase(t *testing.T) {
+	a := newTestApplication("token=abc",
+		testConfigMap("prod", "cfg", map[string]string{"city": "İSTANBUL ẞTRASSE KELVİN token=abc"}),
+	)
+	a.ShowTails = 0
+
+	matches := findMatches(t, a)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	match := matches[0]
+	object := a.KubernetesObjects[0].Object
+
+	if match.Text != "token=abc" || match.Match != "token=abc" {
+		t.Errorf("expected snippet of matched text, got text %q match %q", match.Text, match.Match)
+	}
+
+	if object[match.Offset:match.Offset+len(match.Match)] != "token=abc" {
+		t.Errorf("expected offset %d to point to match in object", match.Offset)
+	}
+}
+
+func TestMultiplePatterns(t *testing.T) {
+	a := newTestApplication("postgres",
+		testConfigMap("prod", "cfg", map[string]string{"url": "postgres://db"}),
+		testPod("prod", "web", "nginx"),
+		testPod("prod", "cache", "redis"),
+	)
+	a.WhatToSearch = []string{"postgres", "nginx"}
+
+	patterns := make(map[string]string)
+	for _, match := range findMatches(t, a) {
+		patterns[match.Kind+"/"+match.Name] = match.Pattern
+	}
+
+	want := map[string]string{"ConfigMaps/cfg": "postgres", "Pods/web": "nginx"}
+	if !maps.Equal(patterns, want) {
+		t.Errorf("expected objects with their patterns %v, got %v", want, patterns)
+	}
+}
+
+func TestCaseSensitive(t *testing.T) {
+	tests := []struct {
+		caseSensitive bool
+		want          []string
+	}{
+		{false, []string{"ConfigMaps/prod/lower", "ConfigMaps/prod/upper"}},
+		{true, []string{"ConfigMaps/prod/upper"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("MyApp",
+			testConfigMap("prod", "upper", map[string]string{"app": "MyApp"}),
+			testConfigMap("prod", "lower", map[string]string{"app": "myapp"}),
+		)
+		a.CaseSensitive = tt.caseSensitive
+
+		matches := findMatches(t, a)
+
+		if got := matchedObjects(matches); !slices.Equal(got, tt.want) {
+			t.Errorf("case-sensitive %v: expected %v, got %v", tt.caseSensitive, tt.want, got)
+		}
+
+		// offsets point to matched text in original object in both modes
+		for i, match := range matches {
+			object := a.KubernetesObjects[slices.IndexFunc(a.KubernetesObjects, func(obj KubernetesObject) bool {
+				return obj.Name == match.Name
+			})].Object
+
+			if !strings.EqualFold(object[match.Offset:match.Offset+len(match.Match)], "MyApp") {
+				t.Errorf("case-sensitive %v: match %d has wrong offset %d", tt.caseSensitive, i, match.Offset)
+			}
+		}
+	}
+}
+
+func TestInvert(t *testing.T) {
+	labeled := testPod("prod", "labeled", "nginx")
+	labeled.Labels = map[string]string{"team": "payments"}
+
+	a := newTestApplication("team: ",
+		labeled,
+		testPod("prod", "unlabeled", "nginx"),
+		testPod("prod", "skipped", "nginx"),
+	)
+	a.WhereToSearch = "pods"
+	a.Invert = true
+	a.Except = "skipped"
+
+	matches := findMatches(t, a)
+
+	if got := matchedObjects(matches); !slices.Equal(got, []string{"Pods/prod/unlabeled"}) {
+		t.Errorf("expected only not matching object, got %v", got)
+	}
+
+	if len(matches) == 1 && matches[0].Text != "" {
+		t.Errorf("expected inverted match without snippet, got %q", matches[0].Text)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(t.Context(), timeout)
+	defer cancel()
+
+	a := newTestApplication("nginx", testPod("prod", "web", "nginx"))
+	a.ResultWriter = &recordWriter{}
+
+	// hung API server answers only when request is cancelled
+	a.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		<-ctx.Done()
+
+		return true, nil, ctx.Err()
+	})
+
+	initApplication(t, a)
+
+	started := time.Now()
+
+	err := a.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed > 10*timeout {
+		t.Errorf("expected run to return after timeout, took %s", elapsed)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	objects := []runtime.Object{testPod("prod", "api", "registry.example.com/api:1.0")}
+
+	tests := []struct {
+		pattern string
+		quiet   bool
+		want    int
+	}{
+		{"registry.example.com", false, 0},
+		{"registry.example.com", true, 0},
+		{"docker.io", false, 1},
+		{"docker.io", true, 1},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication(tt.pattern, objects...)
+		a.ResultWriter = nil
+		a.Quiet = tt.quiet
+		a.OutputFile = filepath.Join(t.TempDir(), "matches.txt")
+
+		initApplication(t, a)
+
+		if err := a.Run(t.Context()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+
+		if got := a.ExitCode(); got != tt.want {
+			t.Errorf("%s quiet=%v: expected exit code %d, got %d", tt.pattern, tt.quiet, tt.want, got)
+		}
+
+		data, err := os.ReadFile(a.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if printed := len(data) > 0; printed != (tt.want == 0 && !tt.quiet) {
+			t.Errorf("%s quiet=%v: unexpected output %q", tt.pattern, tt.quiet, data)
+		}
+	}
+}
+
+func TestValidateInvalidPattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(a *Application)
+	}{
+		{"find", func(a *Application) { a.WhatToSearch = []string{"image: ("} }},
+		{"except", func(a *Application) { a.Except = "[a-" }},
+	}
+
+	for _, tt := range tests {
+		a := NewApplication()
+		a.WhatToSearch = []string{"registry.example.com"}
+		// kubeconfig would fail if cluster setup was reached
+		a.Kubeconfig = filepath.Join(t.TempDir(), "missing")
+		tt.modify(a)
+
+		err := a.Validate()
+		if err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+			t.Errorf("%s: expected invalid pattern error, got %v", tt.name, err)
+		}
+
+		if a.clientset != nil || a.engine != nil {
+			t.Errorf("%s: cluster setup reached in Validate", tt.name)
+		}
+	}
+}
+
+func TestExceptKind(t *testing.T) {
+	objects := []runtime.Object{
+		testPod("prod", "api", "registry.example.com/api:1.0"),
+		testConfigMap("prod", "api", map[string]string{"image": "registry.example.com/api:1.0"}),
+		testConfigMap("prod", "cache", map[string]string{"image": "registry.example.com/cache:1.0"}),
+	}
+
+	tests := []struct {
+		exceptKind string
+		except     string
+		want       []string
+	}{
+		{"", "", []string{"ConfigMaps/prod/api", "ConfigMaps/prod/cache", "Pods/prod/api"}},
+		{"configmaps", "", []string{"Pods/prod/api"}},
+		{"cm", "", []string{"Pods/prod/api"}},
+		// composes with name based -except
+		{"pods", "prod/cache", []string{"ConfigMaps/prod/api"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("registry.example.com", objects...)
+		a.ExceptKind = tt.exceptKind
+		a.Except = tt.except
+
+		got := slices.Sorted(slices.Values(slices.Compact(matchedObjects(findMatches(t, a)))))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("except-kind %q except %q: expected %v, got %v", tt.exceptKind, tt.except, tt.want, got)
+		}
+	}
+}
+
+func TestForbiddenKind(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		a := newTestApplication("registry.example.com",
+			testPod("prod", "api", "registry.example.com/api:1.0"),
+			testConfigMap("prod", "api", map[string]string{"image": "registry.example.com/api:1.0"}),
+			testSecret("prod", "api", map[string]string{"image": "registry.example.com/api:1.0"}),
+		)
+		a.Strict = strict
+
+		a.clientset.(*fake.Clientset).PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", errors.New("rbac"))
+		})
+
+		initApplication(t, a)
+
+		matches, err := a.FindMatches(t.Context())
+
+		if strict {
+			if !apierrors.IsForbidden(errors.Cause(err)) {
+				t.Errorf("strict: expected forbidden error, got %v", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("FindMatches: %v", err)
+		}
+
+		got := slices.Sorted(slices.Values(slices.Compact(matchedObjects(matches))))
+		if want := []string{"ConfigMaps/prod/api", "Pods/prod/api"}; !slices.Equal(got, want) {
+			t.Errorf("expected matches of allowed kinds %v, got %v", want, got)
+		}
+	}
+}
+
+func TestMaxMatches(t *testing.T) {
+	data := make(map[string]string)
+	for i := range 10 {
+		data["file"+strconv.Itoa(i)] = "token=abc"
+	}
+
+	a := newTestApplication("token",
+		testConfigMap("prod", "big", data),
+		testConfigMap("prod", "small", map[string]string{"a": "token=abc", "b": "token=abc"}),
+	)
+	a.WhereToSearch = "configmaps"
+	a.MaxMatches = 3
+
+	matches := findMatches(t, a)
+
+	perObject := make(map[string][]Match)
+	for _, match := range matches {
+		perObject[match.Name] = append(perObject[match.Name], match)
+	}
+
+	if big := perObject["big"]; len(big) != 3 || big[2].MoreMatches != 7 {
+		t.Errorf("expected 3 matches and 7 more in big configmap, got %d matches", len(big))
+	}
+
+	for _, match := range perObject["small"] {
+		if match.MoreMatches != 0 {
+			t.Errorf("unexpected more matches %d in configmap under cap", match.MoreMatches)
+		}
+	}
+
+	if len(perObject["small"]) != 2 {
+		t.Errorf("expected 2 matches in small configmap, got %d", len(perObject["small"]))
+	}
+
+	var buf strings.Builder
+
+	if err := NewTextWriter(slog.New(slog.NewTextHandler(&buf, nil)), false).Write(perObject["big"][2]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "...7 more matches") {
+		t.Errorf("expected more matches note, got %q", buf.String())
+	}
+}
+
+func TestSort(t *testing.T) {
+	a := newTestApplication("registry.example.com",
+		testConfigMap("prod", "web", map[string]string{"image": "registry.example.com/web:1.0"}),
+		testConfigMap("dev", "api", map[string]string{"image": "registry.example.com/api:1.0"}),
+	)
+	a.Sort = true
+
+	// API server does not guarantee order of listed objects
+	a.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.PodList{Items: []corev1.Pod{
+			*testPod("prod", "web", "registry.example.com/web:1.0"),
+			*testPod("dev", "web", "registry.example.com/web:1.0"),
+			*testPod("prod", "api", "registry.example.com/api:1.0"),
+		}}, nil
+	})
+
+	want := []string{
+		"ConfigMaps/dev/api",
+		"ConfigMaps/prod/web",
+		"Pods/dev/web",
+		"Pods/prod/api",
+		"Pods/prod/web",
+	}
+
+	if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, want) {
+		t.Errorf("expected matches sorted by kind, namespace and name %v, got %v", want, got)
+	}
+}
+
+func TestYAMLSnippet(t *testing.T) {
+	tests := []struct {
+		raw  bool
+		want string
+	}{
+		{false, "data:   database_url: postgres://db.example.com metadata:"},
+		// String() of generated struct
+		{true, "{database_url: postgres://db.example.com,}"},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("postgres://",
+			testConfigMap("prod", "api", map[string]string{"database_url": "postgres://db.example.com"}),
+		)
+		a.Raw = tt.raw
+		a.ShowTails = 25
+
+		matches := findMatches(t, a)
+		if len(matches) != 1 {
+			t.Fatalf("raw=%v: expected 1 match, got %d", tt.raw, len(matches))
+		}
+
+		if !strings.Contains(matches[0].Text, tt.want) {
+			t.Errorf("raw=%v: expected %q in snippet, got %q", tt.raw, tt.want, matches[0].Text)
+		}
+	}
+}
+
+func TestPersistentVolumes(t *testing.T) {
+	storageClass := "fast-ssd"
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "prod"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+	}
+
+	other := claim.DeepCopy()
+	other.Namespace = "dev"
+
+	volume := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: storageClass},
+	}
+
+	a := newTestApplication(storageClass, claim, other, volume)
+	// persistent volumes are cluster scoped and not filtered by namespace
+	a.Namespace = "prod"
+
+	got := slices.Compact(matchedObjects(findMatches(t, a)))
+	if want := []string{"PersistentVolumeClaims/prod/data", "PersistentVolumes//pv-data"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRBAC(t *testing.T) {
+	subject := rbacv1.Subject{Kind: "User", Name: "alice@example.com"}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-edit", Namespace: "prod"},
+		Subjects:   []rbacv1.Subject{subject},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "edit"},
+	}
+
+	clusterBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-view"},
+		Subjects:   []rbacv1.Subject{subject},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+	}
+
+	other := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "bob-edit", Namespace: "prod"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", Name: "bob@example.com"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "edit"},
+	}
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "prod"}}
+	clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"alice@example.com", []string{"ClusterRoleBindings//alice-view", "RoleBindings/prod/alice-edit"}},
+		{"(?m)name: (edit|view)$", []string{
+			"ClusterRoleBindings//alice-view", "ClusterRoles//view",
+			"RoleBindings/prod/alice-edit", "RoleBindings/prod/bob-edit", "Roles/prod/edit",
+		}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication(tt.pattern, binding, clusterBinding, other, role, clusterRole)
+		a.Namespace = "prod"
+
+		got := slices.Compact(slices.Sorted(slices.Values(matchedObjects(findMatches(t, a)))))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.pattern, tt.want, got)
+		}
+	}
+}
+
+func TestServiceAccounts(t *testing.T) {
+	account := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:        "uploader",
+		Namespace:   "prod",
+		Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/uploader"},
+	}}
+
+	other := account.DeepCopy()
+	other.Namespace = "dev"
+
+	for _, where := range []string{"", "serviceaccounts", "pods"} {
+		a := newTestApplication("role/uploader", account, other)
+		a.Namespace = "prod"
+		a.WhereToSearch = where
+
+		var want []string
+		if where != "pods" {
+			want = []string{"ServiceAccounts/prod/uploader"}
+		}
+
+		if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, want) {
+			t.Errorf("where %q: expected %v, got %v", where, want, got)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	event := func(namespace, name, message string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: "api"},
+			Reason:         "BackOff",
+			Message:        message,
+		}
+	}
+
+	a := newTestApplication("back-off restarting",
+		event("prod", "api.1", "Back-off restarting failed container api"),
+		event("prod", "api.2", "Pulling image registry.example.com/api:1.0"),
+		event("dev", "api.1", "Back-off restarting failed container api"),
+	)
+	a.WhereToSearch = "events"
+	a.Namespace = "prod"
+	a.PageSize = 1
+
+	limits := make([]int64, 0)
+
+	a.clientset.(*fake.Clientset).PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		limits = append(limits, action.(k8stesting.ListActionImpl).ListOptions.Limit)
+
+		return false, nil, nil
+	})
+
+	matches := findMatches(t, a)
+	if got := slices.Compact(matchedObjects(matches)); !slices.Equal(got, []string{"Events/prod/api.1"}) {
+		t.Errorf("expected only matching event of namespace, got %v", got)
+	}
+
+	if len(limits) == 0 || slices.ContainsFunc(limits, func(limit int64) bool { return limit != a.PageSize }) {
+		t.Errorf("expected events listed in pages of %d, got limits %v", a.PageSize, limits)
+	}
+}
+
+func TestAutoscalersAndDisruptionBudgets(t *testing.T) {
+	autoscaler := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "prod"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "checkout-api"},
+			MaxReplicas:    10,
+		},
+	}
+
+	other := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "cart", Namespace: "prod"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "cart-api"},
+			MaxReplicas:    10,
+		},
+	}
+
+	budget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "prod"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "checkout-api"}},
+		},
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"name: checkout-api", []string{"HorizontalPodAutoscalers/prod/checkout"}},
+		{"app: checkout-api", []string{"PodDisruptionBudgets/prod/checkout"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication(tt.pattern, autoscaler, other, budget)
+
+		if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.pattern, tt.want, got)
+		}
+	}
+}
+
+func TestNetworkPolicies(t *testing.T) {
+	policy := func(namespace, name, cidr string) *networkingv1.NetworkPolicy {
+		return &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}},
+				}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			},
+		}
+	}
+
+	a := newTestApplication(`10\.20\.0\.0/16`,
+		policy("prod", "api-egress", "10.20.0.0/16"),
+		policy("prod", "web-egress", "10.30.0.0/16"),
+		policy("dev", "api-egress", "10.20.0.0/16"),
+	)
+	a.Namespace = "prod"
+
+	if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, []string{"NetworkPolicies/prod/api-egress"}) {
+		t.Errorf("expected network policy with cidr in egress rule, got %v", got)
+	}
+}
+
+func TestMaxObjectSize(t *testing.T) {
+	logs := captureLogs(t)
+
+	a := newTestApplication("token",
+		testConfigMap("prod", "bundle", map[string]string{"bundle.js": "token=abc " + strings.Repeat("x", 10000)}),
+		testConfigMap("prod", "settings", map[string]string{"auth": "token=abc"}),
+	)
+	a.WhereToSearch = "configmaps"
+	a.MaxObjectSize = 1000
+
+	if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, []string{"ConfigMaps/prod/settings"}) {
+		t.Errorf("expected only normal object searched, got %v", got)
+	}
+
+	if !strings.Contains(logs.String(), `msg="object is too big, it is not searched" kind=ConfigMaps name=bundle namespace=prod`) {
+		t.Errorf("expected warning about skipped object, got %q", logs)
+	}
+}
+
+func TestEndpointSlices(t *testing.T) {
+	slice := func(namespace, name, address string) *discoveryv1.EndpointSlice {
+		port := int32(8080)
+
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "api"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{{
+				Addresses: []string{address},
+				TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: "api-7d9f"},
+			}},
+			Ports: []discoveryv1.EndpointPort{{Port: &port}},
+		}
+	}
+
+	a := newTestApplication(`(?m)- 10\.1\.2\.3$`,
+		slice("prod", "api-x1", "10.1.2.3"),
+		slice("prod", "api-x2", "10.1.2.30"),
+		slice("dev", "api-x1", "10.1.2.3"),
+	)
+	a.Namespace = "prod"
+
+	if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, []string{"EndpointSlices/prod/api-x1"}) {
+		t.Errorf("expected endpoint slice with backend address, got %v", got)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	namespace := func(name, team string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{"example.com/team": team},
+		}}
+	}
+
+	for _, scope := range []string{ScopeAll, ScopeMetadata} {
+		a := newTestApplication("payments",
+			namespace("checkout", "payments"),
+			namespace("search", "discovery"),
+			testPod("checkout", "api", "registry.example.com/payments:1.0"),
+		)
+		a.WhereToSearch = "namespaces"
+		// namespaces are cluster scoped and not filtered by namespace
+		a.Namespace = "search"
+		a.Scope = scope
+
+		if got := slices.Compact(matchedObjects(findMatches(t, a))); !slices.Equal(got, []string{"Namespaces//checkout"}) {
+			t.Errorf("scope %s: expected namespace with annotation, got %v", scope, got)
+		}
+	}
+}
+
+func TestExceptFormat(t *testing.T) {
+	objects := []runtime.Object{
+		testPod("kube-system", "proxy", "registry.example.com/proxy:1.0"),
+		testPod("prod", "api", "registry.example.com/api:1.0"),
+		testConfigMap("kube-system", "proxy", map[string]string{"image": "registry.example.com/proxy:1.0"}),
+	}
+
+	tests := []struct {
+		format string
+		except string
+		want   []string
+	}{
+		{ExceptFormatName, "kube-system/.*", []string{"Pods/prod/api"}},
+		// kind is not part of target in backward compatible format
+		{ExceptFormatName, "Pods/kube-system/.*", []string{"ConfigMaps/kube-system/proxy", "Pods/kube-system/proxy", "Pods/prod/api"}},
+		{ExceptFormatKind, "Pods/kube-system/.*", []string{"ConfigMaps/kube-system/proxy", "Pods/prod/api"}},
+		{ExceptFormatKind, "^kube-system/.*", []string{"ConfigMaps/kube-system/proxy", "Pods/kube-system/proxy", "Pods/prod/api"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("registry.example.com", objects...)
+		a.ExceptFormat = tt.format
+		a.Except = tt.except
+
+		got := slices.Compact(slices.Sorted(slices.Values(matchedObjects(findMatches(t, a)))))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s %q: expected %v, got %v", tt.format, tt.except, tt.want, got)
+		}
+	}
+}
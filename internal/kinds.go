@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// kindAliases maps singular forms of kinds to kinds used in -where,
+// short names are taken from shortKinds.
+var kindAliases = map[string]string{
+	"pod":                     "pods",
+	"configmap":               "configmaps",
+	"secret":                  "secrets",
+	"deployment":              "deployments",
+	"statefulset":             "statefulsets",
+	"daemonset":               "daemonsets",
+	"replicaset":              "replicasets",
+	"job":                     "jobs",
+	"cronjob":                 "cronjobs",
+	"service":                 "services",
+	"ingress":                 "ingresses",
+	"endpointslice":           "endpointslices",
+	"networkpolicy":           "networkpolicies",
+	"persistentvolumeclaim":   "persistentvolumeclaims",
+	"persistentvolume":        "persistentvolumes",
+	"horizontalpodautoscaler": "horizontalpodautoscalers",
+	"poddisruptionbudget":     "poddisruptionbudgets",
+	"serviceaccount":          "serviceaccounts",
+	"event":                   "events",
+	"role":                    "roles",
+	"rolebinding":             "rolebindings",
+	"clusterrole":             "clusterroles",
+	"clusterrolebinding":      "clusterrolebindings",
+	"namespace":               "namespaces",
+	"componentstatus":         "componentstatuses",
+}
+
+// resolveKind returns lowercased kind for alias, unknown names are returned as is.
+func resolveKind(name string) string {
+	name = strings.ToLower(name)
+
+	if kind, ok := kindAliases[name]; ok {
+		return kind
+	}
+
+	for kind, short := range shortKinds {
+		if short == name {
+			return strings.ToLower(kind)
+		}
+	}
+
+	return name
+}
+
+// isKnownKind reports whether there is getter for lowercased kind.
+func (a *Application) isKnownKind(kind string) bool {
+	if a.gvr != nil && kind == strings.ToLower(a.gvr.GroupResource().String()) {
+		return true
+	}
+
+	return slices.Contains(slices.Collect(maps.Values(kindAliases)), kind)
+}
+
+// validateKinds warns about unknown kinds in -where and -except-kind,
+// with -strict they are errors.
+func (a *Application) validateKinds() error {
+	entries := append(a.whereEntries(), listEntries(a.ExceptKind)...)
+
+	for _, entry := range entries {
+		if entry == "" || entry == "*" || entry == WhereLocal || entry == WhereCluster {
+			continue
+		}
+
+		if a.isKnownKind(resolveKind(entry)) {
+			continue
+		}
+
+		if a.Strict {
+			return errors.Errorf("unknown kind %q", entry)
+		}
+
+		slog.Warn("unknown kind, it is ignored", "kind", entry)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs returns buffer with logs written until end of test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	return &buf
+}
+
+func TestKindAliases(t *testing.T) {
+	tests := []struct {
+		where string
+		kind  string
+		want  bool
+	}{
+		{"po", "Pods", true},
+		{"pod", "Pods", true},
+		{"Pods", "Pods", true},
+		{"deploy", "Deployments", true},
+		{"deployment", "Deployments", true},
+		{"sts", "StatefulSets", true},
+		{"cm", "ConfigMaps", true},
+		{"cj", "CronJobs", true},
+		{"cj", "Jobs", false},
+		{"po", "PodDisruptionBudgets", false},
+	}
+
+	for _, tt := range tests {
+		a := NewApplication()
+		a.WhereToSearch = tt.where
+
+		if got := a.isInWhere(tt.kind); got != tt.want {
+			t.Errorf("where %q kind %s: expected %v, got %v", tt.where, tt.kind, tt.want, got)
+		}
+	}
+}
+
+func TestKindAliasUnknown(t *testing.T) {
+	logs := captureLogs(t)
+
+	a := NewApplication()
+	a.WhatToSearch = []string{"x"}
+	a.WhereToSearch = "po,pds"
+
+	if err := a.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), `msg="unknown kind, it is ignored" kind=pds`) {
+		t.Errorf("expected warning about unknown kind, got %q", logs)
+	}
+
+	if strings.Contains(logs.String(), "kind=po") {
+		t.Errorf("alias reported as unknown kind: %q", logs)
+	}
+}
+
+func TestValidateKinds(t *testing.T) {
+	tests := []struct {
+		where      string
+		exceptKind string
+		strict     bool
+		unknown    string
+	}{
+		{"deployment,ingres", "", false, "ingres"},
+		{"deployment,ingres", "", true, "ingres"},
+		{"pods", "confgmaps", false, "confgmaps"},
+		{"pods", "confgmaps", true, "confgmaps"},
+		{"pods,ingresses", "configmaps", true, ""},
+	}
+
+	for _, tt := range tests {
+		logs := captureLogs(t)
+
+		a := NewApplication()
+		a.WhatToSearch = []string{"x"}
+		a.WhereToSearch = tt.where
+		a.ExceptKind = tt.exceptKind
+		a.Strict = tt.strict
+
+		err := a.Validate()
+
+		switch {
+		case tt.unknown == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", tt.where, err)
+		case tt.unknown != "" && tt.strict && (err == nil || !strings.Contains(err.Error(), tt.unknown)):
+			t.Errorf("%q strict: expected error about %s, got %v", tt.where, tt.unknown, err)
+		case tt.unknown != "" && !tt.strict && !strings.Contains(logs.String(), "kind="+tt.unknown):
+			t.Errorf("%q: expected warning about %s, got %q", tt.where, tt.unknown, logs)
+		}
+
+		if tt.unknown == "" && strings.Contains(logs.String(), "unknown kind") {
+			t.Errorf("%q: unexpected warning %q", tt.where, logs)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+)
+
+// inClusterContext is context name of service account mounted into pod.
+const inClusterContext = "in-cluster"
+
+// restConfig returns config of cluster to search in and name of its context.
+// Kubeconfig is -kubeconfig, $KUBECONFIG or ~/.kube/config like in kubectl,
+// service account of pod is used with -in-cluster or when none of them exists,
+// context is current context of kubeconfig or one from -context.
+func (a *Application) restConfig() (*rest.Config, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = a.Kubeconfig
+
+	// clientcmd resolves home once when loaded, it is resolved on every call instead
+	if os.Getenv(clientcmd.RecommendedConfigPathEnvVar) == "" {
+		loadingRules.Precedence = []string{filepath.Join(homedir.HomeDir(), clientcmd.RecommendedHomeDir, clientcmd.RecommendedFileName)}
+	}
+
+	// missing -kubeconfig is a mistake, in-cluster config is not used instead
+	if loadingRules.ExplicitPath != "" && !a.InCluster {
+		if _, err := os.Stat(loadingRules.ExplicitPath); err != nil {
+			return nil, "", errors.Wrap(err, "error in reading kubeconfig")
+		}
+	}
+
+	if a.InCluster || !hasKubeconfig(loadingRules) {
+		restconfig, err := rest.InClusterConfig()
+		if err != nil {
+			if !a.InCluster {
+				return nil, "", errors.Wrap(err, "kubeconfig is required outside of cluster")
+			}
+
+			return nil, "", errors.Wrap(err, "error in rest.InClusterConfig")
+		}
+
+		return restconfig, inClusterContext, nil
+	}
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: a.KubeContext},
+	)
+
+	kubeconfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error in clientConfig.RawConfig")
+	}
+
+	clusterContext := kubeconfig.CurrentContext
+
+	if a.KubeContext != "" {
+		if _, ok := kubeconfig.Contexts[a.KubeContext]; !ok {
+			return nil, "", errors.Errorf("context %s not found in kubeconfig", a.KubeContext)
+		}
+
+		clusterContext = a.KubeContext
+	}
+
+	restconfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error in clientConfig.ClientConfig")
+	}
+
+	return restconfig, clusterContext, nil
+}
+
+// hasKubeconfig reports whether any kubeconfig file of loading rules exists.
+func hasKubeconfig(loadingRules *clientcmd.ClientConfigLoadingRules) bool {
+	for _, path := range loadingRules.GetLoadingPrecedence() {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
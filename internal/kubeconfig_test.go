@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+- name: dev
+  context:
+    cluster: dev
+current-context: prod
+`
+
+func TestRestConfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		context     string
+		wantContext string
+		wantHost    string
+	}{
+		{"", "prod", "https://prod.example.com"},
+		{"dev", "dev", "https://dev.example.com"},
+	}
+
+	for _, tt := range tests {
+		a := NewApplication()
+		a.Kubeconfig = kubeconfig
+		a.KubeContext = tt.context
+
+		restconfig, clusterContext, err := a.restConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if clusterContext != tt.wantContext || restconfig.Host != tt.wantHost {
+			t.Errorf("context %q: expected %s %s, got %s %s", tt.context, tt.wantContext, tt.wantHost, clusterContext, restconfig.Host)
+		}
+	}
+}
+
+func TestRestConfigUnknownContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewApplication()
+	a.Kubeconfig = kubeconfig
+	a.KubeContext = "staging"
+
+	if _, _, err := a.restConfig(); err == nil {
+		t.Fatal("expected error for unknown context")
+	}
+}
+
+func TestRestConfigMissingKubeconfig(t *testing.T) {
+	a := NewApplication()
+	a.Kubeconfig = filepath.Join(t.TempDir(), "missing")
+
+	_, _, err := a.restConfig()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected file not found error, got %v", err)
+	}
+}
+
+func TestRestConfigSelection(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// outside of pod there is no service account
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	// home with ~/.kube/config of dev cluster
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".kube"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	homeKubeconfig := strings.Replace(testKubeconfig, "current-context: prod", "current-context: dev", 1)
+	if err := os.WriteFile(filepath.Join(home, ".kube", "config"), []byte(homeKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		env         string
+		home        string
+		inCluster   bool
+		wantErr     string
+		wantContext string
+	}{
+		{"kubeconfig from env", kubeconfig, home, false, "", "prod"},
+		{"kubeconfig from home", "", home, false, "", "dev"},
+		{"no kubeconfig", filepath.Join(t.TempDir(), "missing"), home, false, "kubeconfig is required outside of cluster", ""},
+		{"no kubeconfig in home", "", t.TempDir(), false, "kubeconfig is required outside of cluster", ""},
+		{"forced in-cluster", kubeconfig, home, true, "error in rest.InClusterConfig", ""},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("KUBECONFIG", tt.env)
+		t.Setenv("HOME", tt.home)
+
+		a := NewApplication()
+		a.InCluster = tt.inCluster
+
+		restconfig, clusterContext, err := a.restConfig()
+
+		switch {
+		case tt.wantErr != "":
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case clusterContext != tt.wantContext || restconfig.Host != "https://"+tt.wantContext+".example.com":
+			t.Errorf("%s: expected context %s, got %s with host %s", tt.name, tt.wantContext, clusterContext, restconfig.Host)
+		}
+	}
+}
+
+func TestInitContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewApplication()
+	a.WhatToSearch = []string{"x"}
+	a.Kubeconfig = kubeconfig
+	a.KubeContext = "dev"
+	a.ClusterLabel = "{{.Context}}"
+
+	initApplication(t, a)
+
+	if a.clusterContext != "dev" || a.clusterHost != "https://dev.example.com" || a.cluster != "dev" {
+		t.Errorf("expected dev cluster, got context %q host %q label %q", a.clusterContext, a.clusterHost, a.cluster)
+	}
+
+	a.clientset = nil
+	a.KubeContext = "staging"
+
+	if err := a.Init(t.Context()); err == nil || !strings.Contains(err.Error(), "context staging not found") {
+		t.Errorf("expected unknown context error, got %v", err)
+	}
+}
+
+func TestQPSAndBurst(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewApplication()
+	a.WhatToSearch = []string{"x"}
+	a.Kubeconfig = kubeconfig
+	// rate is low, so only burst of requests is accepted without waiting
+	a.QPS = 0.001
+	a.Burst = 3
+
+	initApplication(t, a)
+
+	clientset, ok := a.clientset.(*kubernetes.Clientset)
+	if !ok {
+		t.Fatalf("expected kubernetes clientset, got %T", a.clientset)
+	}
+
+	limiter := clientset.CoreV1().RESTClient().GetRateLimiter()
+	if limiter.QPS() != float32(a.QPS) {
+		t.Errorf("expected qps %v, got %v", a.QPS, limiter.QPS())
+	}
+
+	accepted := 0
+	for range a.Burst + 1 {
+		if limiter.TryAccept() {
+			accepted++
+		}
+	}
+
+	if accepted != a.Burst {
+		t.Errorf("expected burst %d, got %d", a.Burst, accepted)
+	}
+}
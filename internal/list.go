@@ -0,0 +1,731 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+type listFunc func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error)
+
+// KindStat is outcome of fetching one kind of objects, Errs are errors
+// of namespaces which could not be listed.
+type KindStat struct {
+	Kind      string
+	Objects   int
+	Errs      map[string]error
+	Truncated bool
+	random    *rand.Rand
+	reported  time.Time
+	listed    map[string]bool
+}
+
+// failedNamespaces returns sorted namespaces which could not be listed.
+func (s *KindStat) failedNamespaces() string {
+	return strings.Join(slices.Sorted(maps.Keys(s.Errs)), ",")
+}
+
+// partial reports whether kind was listed only in some namespaces.
+func (s *KindStat) partial() bool {
+	return len(s.listed) > 0
+}
+
+// progressInterval is how often number of fetched objects is logged with -progress.
+var progressInterval = 5 * time.Second
+
+// namespaces returns namespaces from comma separated -namespace, all namespaces when empty.
+func (a *Application) namespaces() []string {
+	namespaces := make([]string, 0)
+
+	for _, namespace := range strings.Split(a.Namespace, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	if len(namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+
+	return namespaces
+}
+
+// list fetches namespaced objects of kind with listFunc once per namespace
+// and appends them to KubernetesObjects.
+func (a *Application) list(ctx context.Context, typeOf string, list listFunc) error {
+	return a.listIn(ctx, typeOf, a.namespaces(), list)
+}
+
+// listClusterScoped fetches objects of kind that do not belong to namespace.
+func (a *Application) listClusterScoped(ctx context.Context, typeOf string, list listFunc) error {
+	return a.listIn(ctx, typeOf, []string{metav1.NamespaceAll}, list)
+}
+
+func (a *Application) listIn(ctx context.Context, typeOf string, namespaces []string, list listFunc) error {
+	if !a.isInWhere(typeOf) || a.isExceptKind(typeOf) {
+		return nil
+	}
+
+	slog.Info("Getting " + typeOf + " ...")
+
+	// every kind has own random source, so -sample-seed results
+	// do not depend on order in which concurrent lists finish
+	kindSeed := fnv.New64a()
+	kindSeed.Write([]byte(typeOf))
+
+	stat := &KindStat{
+		Kind:     typeOf,
+		Errs:     make(map[string]error),
+		random:   rand.New(rand.NewPCG(a.sampleSeed, kindSeed.Sum64())),
+		reported: time.Now(),
+		listed:   make(map[string]bool),
+	}
+
+	a.mu.Lock()
+	a.KindStats = append(a.KindStats, stat)
+	a.mu.Unlock()
+
+	for _, namespace := range namespaces {
+		if a.LimitPerKind > 0 && int64(stat.Objects) >= a.LimitPerKind {
+			stat.Truncated = true
+
+			break
+		}
+
+		served, err := a.listNamespace(ctx, stat, namespace, list)
+		if err != nil {
+			return err
+		}
+
+		if !served {
+			break
+		}
+	}
+
+	if stat.Truncated {
+		slog.Warn(typeOf+" truncated", "limit", a.LimitPerKind)
+	}
+
+	return nil
+}
+
+// listNamespace fetches objects of one namespace page by page, it reports false when
+// API of kind is not served and other namespaces must not be tried.
+func (a *Application) listNamespace(ctx context.Context, stat *KindStat, namespace string, list listFunc) (bool, error) {
+	if a.CountObjects {
+		return a.countNamespace(ctx, stat, namespace, list)
+	}
+
+	opts := a.listOptions()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, errors.Wrap(err, "error in "+stat.Kind)
+		}
+
+		opts.Limit = a.pageLimit(stat)
+
+		objects, served, err := a.listPage(ctx, stat, namespace, opts, list)
+		if err != nil || objects == nil {
+			return served, err
+		}
+
+		if err := a.appendItems(ctx, stat, objects, opts.Limit); err != nil {
+			return false, err
+		}
+
+		a.reportProgress(stat)
+
+		listMeta, err := meta.ListAccessor(objects)
+		if err != nil || listMeta.GetContinue() == "" {
+			return true, nil
+		}
+
+		if a.LimitPerKind > 0 && int64(stat.Objects) >= a.LimitPerKind {
+			stat.Truncated = true
+
+			return true, nil
+		}
+
+		opts.Continue = listMeta.GetContinue()
+	}
+}
+
+// reportProgress logs number of fetched objects of kind at most once
+// per progressInterval, pages of small kinds are fetched silently.
+func (a *Application) reportProgress(stat *KindStat) {
+	if !a.progress || time.Since(stat.reported) < progressInterval {
+		return
+	}
+
+	stat.reported = time.Now()
+
+	slog.Info("fetching "+stat.Kind+" ...", "objects", stat.Objects)
+}
+
+// countNamespace counts objects of one namespace with -count-objects.
+func (a *Application) countNamespace(ctx context.Context, stat *KindStat, namespace string, list listFunc) (bool, error) {
+	opts := a.listOptions()
+	opts.Limit = 1
+
+	objects, served, err := a.listPage(ctx, stat, namespace, opts, list)
+	if err != nil || objects == nil {
+		return served, err
+	}
+
+	count, complete, err := countObjects(objects)
+	if err != nil {
+		return false, errors.Wrap(err, "error in countObjects "+stat.Kind)
+	}
+
+	// server does not report remaining items, list all objects of namespace
+	if !complete {
+		opts.Limit = 0
+
+		objects, served, err = a.listPage(ctx, stat, namespace, opts, list)
+		if err != nil || objects == nil {
+			return served, err
+		}
+
+		count = meta.LenList(objects)
+	}
+
+	stat.Objects += count
+
+	return true, nil
+}
+
+// listOptions returns options shared by all list requests.
+func (a *Application) listOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: a.Selector,
+		FieldSelector: a.FieldSelector,
+	}
+}
+
+// listPage makes one list request, objects are nil when kind is skipped
+// and served is false when kind can not be listed in any namespace.
+func (a *Application) listPage(ctx context.Context, stat *KindStat, namespace string, opts metav1.ListOptions, list listFunc) (runtime.Object, bool, error) {
+	var objects runtime.Object
+
+	attempt, exhausted := 0, false
+
+	retriable := func(err error) bool {
+		// after last attempt there is no retry to take from budget
+		if !isTransient(err) || attempt > a.Retries {
+			return false
+		}
+
+		if !a.takeRetry() {
+			exhausted = true
+
+			return false
+		}
+
+		return true
+	}
+
+	err := retry.OnError(a.retryBackoff(), retriable, func() error {
+		if attempt++; attempt > 1 {
+			slog.Warn(stat.Kind+" list failed, retrying", "namespace", namespace, "attempt", attempt)
+		}
+
+		listCtx, cancel := a.listContext(ctx)
+		defer cancel()
+
+		var err error
+
+		objects, err = list(listCtx, namespace, opts)
+
+		return err
+	})
+	if err != nil {
+		stat.Errs[namespace] = err
+	} else {
+		stat.listed[namespace] = true
+	}
+
+	if exhausted {
+		return nil, false, errors.Wrapf(err, "error in %s, all %d retries of -max-total-retries are used", stat.Kind, a.MaxTotalRetries)
+	}
+
+	if a.isListTimeout(ctx, err) {
+		slog.Warn(stat.Kind+" list timed out, skipping", "namespace", namespace, "timeout", a.ListTimeout)
+
+		return nil, true, nil
+	}
+
+	if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+		slog.Warn(stat.Kind+" API is not served by this cluster, skipping", "error", err)
+
+		return nil, false, nil
+	}
+
+	// with restricted access some kinds or namespaces can not be listed
+	if apierrors.IsForbidden(err) && !a.Strict {
+		slog.Warn(stat.Kind+" list is forbidden, skipping", "namespace", namespace, "error", err)
+
+		return nil, true, nil
+	}
+
+	// field selectors are supported only for some fields of each kind
+	if opts.FieldSelector != "" && apierrors.IsBadRequest(err) {
+		slog.Warn(stat.Kind+" does not support field selector, skipping", "fieldSelector", opts.FieldSelector, "error", err)
+
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.Wrap(err, "error in "+stat.Kind)
+	}
+
+	return objects, true, nil
+}
+
+// retryBackoff returns exponential backoff with -retries attempts after first one.
+func (a *Application) retryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Steps:    a.Retries + 1,
+		Duration: 500 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.1,
+	}
+}
+
+// takeRetry reports whether one more list retry fits in -max-total-retries,
+// budget is shared by all kinds and namespaces listed concurrently.
+func (a *Application) takeRetry() bool {
+	if a.MaxTotalRetries <= 0 {
+		return true
+	}
+
+	return a.totalRetries.Add(1) <= a.MaxTotalRetries
+}
+
+// isTransient reports whether failed list request can succeed when retried,
+// authorization and client errors are not retried.
+func isTransient(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsUnexpectedServerError(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// pageLimit returns limit for next list request from -page-size
+// and what is left of -limit-per-kind.
+func (a *Application) pageLimit(stat *KindStat) int64 {
+	limit := a.PageSize
+
+	if a.LimitPerKind > 0 {
+		remaining := a.LimitPerKind - int64(stat.Objects)
+		if limit <= 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+
+	return limit
+}
+
+// appendItems passes items of one page to search.
+func (a *Application) appendItems(ctx context.Context, stat *KindStat, objects runtime.Object, limit int64) error {
+	items, err := meta.ExtractList(objects)
+	if err != nil {
+		return errors.Wrap(err, "error in meta.ExtractList")
+	}
+
+	if a.LimitPerKind > 0 {
+		items = limitItems(stat, items, limit)
+	}
+
+	stat.Objects += len(items)
+
+	page, err := a.pageObjects(stat, objects, items)
+	if err != nil {
+		return err
+	}
+
+	// in streaming mode objects are searched as soon as they are fetched
+	if a.objects != nil {
+		for _, obj := range page {
+			select {
+			case a.objects <- obj:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.KubernetesObjects = append(a.KubernetesObjects, page...)
+
+	return nil
+}
+
+// pageObjects converts items of one page to objects to search in.
+func (a *Application) pageObjects(stat *KindStat, objects runtime.Object, items []runtime.Object) ([]KubernetesObject, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.observeResourceVersion(objects)
+
+	page := make([]KubernetesObject, 0, len(items))
+
+	for _, item := range items {
+		if !a.sampled(stat) {
+			continue
+		}
+
+		object, ok, err := a.kubernetesObject(stat.Kind, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && a.firstListed(object) {
+			page = append(page, object)
+		}
+	}
+
+	return page, nil
+}
+
+// firstListed reports whether object is listed first time, so objects
+// listed twice by overlapping requests are searched once.
+func (a *Application) firstListed(obj KubernetesObject) bool {
+	if a.listed == nil {
+		a.listed = make(map[string]bool)
+	}
+
+	key := obj.Kind + "/" + obj.Namespace + "/" + obj.Name
+	if a.listed[key] {
+		return false
+	}
+
+	a.listed[key] = true
+
+	return true
+}
+
+// kubernetesObject returns object to search, it is false when object
+// is filtered out by -skip-terminating, -since-resource-version or -since.
+func (a *Application) kubernetesObject(kind string, item runtime.Object) (KubernetesObject, bool, error) {
+	object, err := meta.Accessor(item)
+	if err != nil {
+		return KubernetesObject{}, false, errors.Wrap(err, "error in meta.Accessor")
+	}
+
+	created := objectTime(item, object)
+
+	if !a.isInTerminating(object) || !a.isChangedSince(object) || !a.isRecent(created) {
+		return KubernetesObject{}, false, nil
+	}
+
+	a.removeUnnecessaryAnnotations(object)
+
+	return KubernetesObject{
+		Kind:            kind,
+		Name:            object.GetName(),
+		Namespace:       object.GetNamespace(),
+		Object:          objectText(item, a.Raw),
+		OwnerReferences: object.GetOwnerReferences(),
+		Raw:             item,
+		Node:            nodeName(item),
+		Labels:          object.GetLabels(),
+		Annotations:     object.GetAnnotations(),
+		DeletionTime:    object.GetDeletionTimestamp(),
+		GVK:             objectKind(item),
+		Created:         created,
+	}, true, nil
+}
+
+// countObjects returns number of objects from first page of list with limit,
+// it is not complete when server does not report remaining items.
+func countObjects(objects runtime.Object) (int, bool, error) {
+	listMeta, err := meta.ListAccessor(objects)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "error in meta.ListAccessor")
+	}
+
+	count := meta.LenList(objects)
+
+	if remaining := listMeta.GetRemainingItemCount(); remaining != nil {
+		return count + int(*remaining), true, nil
+	}
+
+	return count, listMeta.GetContinue() == "", nil
+}
+
+// limitItems cuts items to what is left of -limit-per-kind, it is needed
+// when server does not respect limit of list request.
+func limitItems(stat *KindStat, items []runtime.Object, limit int64) []runtime.Object {
+	if int64(len(items)) > limit {
+		items = items[:limit]
+		stat.Truncated = true
+	}
+
+	return items
+}
+
+// objectText returns text of object to search in, it is yaml
+// like in kubectl get -o yaml, with raw it is Go representation of object.
+func objectText(obj runtime.Object, raw bool) string {
+	if !raw {
+		return yamlText(obj)
+	}
+
+	// custom resources have no String, search in their json instead
+	if object, ok := obj.(*unstructured.Unstructured); ok {
+		if data, err := object.MarshalJSON(); err == nil {
+			return string(data)
+		}
+	}
+
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return fmt.Sprint(obj)
+	}
+
+	// secret data is bytes, search in decoded values instead
+	withoutData := secret.DeepCopy()
+	withoutData.Data = nil
+
+	var text strings.Builder
+
+	text.WriteString(withoutData.String())
+
+	for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+		text.WriteString("\n" + key + ": " + string(secret.Data[key]))
+	}
+
+	return text.String()
+}
+
+// yamlText returns object as yaml with decoded secret data.
+func yamlText(obj runtime.Object) string {
+	secret, isSecret := obj.(*corev1.Secret)
+	if isSecret {
+		// secret data is base64 in yaml, search in decoded values instead
+		withoutData := secret.DeepCopy()
+		withoutData.Data = nil
+		obj = withoutData
+	}
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Sprint(obj)
+	}
+
+	var text strings.Builder
+
+	text.Write(data)
+
+	if isSecret && len(secret.Data) > 0 {
+		text.WriteString("data:")
+
+		for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+			text.WriteString("\n  " + key + ": " + string(secret.Data[key]))
+		}
+	}
+
+	return text.String()
+}
+
+func objectKind(obj runtime.Object) schema.GroupVersionKind {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil || len(gvks) == 0 {
+		return obj.GetObjectKind().GroupVersionKind()
+	}
+
+	return gvks[0]
+}
+
+func nodeName(obj runtime.Object) string {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		return pod.Spec.NodeName
+	}
+
+	return ""
+}
+
+func (a *Application) listContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.ListTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, a.ListTimeout)
+}
+
+func (a *Application) isListTimeout(ctx context.Context, err error) bool {
+	if err == nil || a.ListTimeout <= 0 || ctx.Err() != nil {
+		return false
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) || apierrors.IsTimeout(err)
+}
+
+// sampled reports whether next object should be included with -sample.
+func (a *Application) sampled(stat *KindStat) bool {
+	if a.Sample >= 1 {
+		return true
+	}
+
+	return stat.random.Float64() < a.Sample
+}
+
+// isInTerminating filters objects by deletion state with -skip-terminating and -only-terminating.
+func (a *Application) isInTerminating(obj metav1.Object) bool {
+	terminating := obj.GetDeletionTimestamp() != nil
+
+	switch {
+	case a.SkipTerminating:
+		return !terminating
+	case a.OnlyTerminating:
+		return terminating
+	default:
+		return true
+	}
+}
+
+// isChangedSince reports whether object was modified after -since-resource-version.
+// Resource versions are compared as numbers, Kubernetes does not guarantee it
+// but it holds for etcd based API servers.
+func (a *Application) isChangedSince(obj metav1.Object) bool {
+	if a.SinceResourceVersion == "" {
+		return true
+	}
+
+	resourceVersion, err := strconv.ParseUint(obj.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return true
+	}
+
+	return resourceVersion > a.sinceResourceVersion
+}
+
+// isRecent reports whether object was created or event was last seen within -since.
+func (a *Application) isRecent(created time.Time) bool {
+	return a.Since == 0 || created.After(time.Now().Add(-a.Since))
+}
+
+// objectTime returns creation time of object, for events it is time
+// they were last seen.
+func objectTime(item runtime.Object, obj metav1.Object) time.Time {
+	event, ok := item.(*corev1.Event)
+	if !ok {
+		return obj.GetCreationTimestamp().Time
+	}
+
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return obj.GetCreationTimestamp().Time
+	}
+}
+
+// observeResourceVersion remembers latest resource version of lists to resume next run from.
+func (a *Application) observeResourceVersion(objects runtime.Object) {
+	listMeta, err := meta.ListAccessor(objects)
+	if err != nil {
+		return
+	}
+
+	resourceVersion, err := strconv.ParseUint(listMeta.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return
+	}
+
+	a.latestResourceVersion = max(a.latestResourceVersion, resourceVersion)
+}
+
+func (a *Application) removeUnnecessaryAnnotations(obj metav1.Object) {
+	annotations := obj.GetAnnotations()
+
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+}
+
+func (a *Application) printObjectsCount() {
+	total := 0
+
+	for _, stat := range a.KindStats {
+		if len(stat.Errs) > 0 && !stat.partial() {
+			slog.Warn("kind can not be counted", "kind", stat.Kind, "errors", stat.Errs)
+
+			continue
+		}
+
+		if len(stat.Errs) > 0 {
+			slog.Warn("kind counted partially", "kind", stat.Kind, "failedNamespaces", stat.failedNamespaces())
+		}
+
+		total += stat.Objects
+
+		slog.Info("objects to search", "kind", stat.Kind, "objects", stat.Objects)
+	}
+
+	slog.Info("objects to search", "total", total)
+}
+
+func (a *Application) printKindStats() {
+	objects := 0
+
+	for _, stat := range a.KindStats {
+		objects += stat.Objects
+
+		switch {
+		case len(stat.Errs) > 0 && !stat.partial():
+			slog.Warn("kind was not searched", "kind", stat.Kind, "errors", stat.Errs)
+		case len(stat.Errs) > 0:
+			slog.Warn("kind searched partially", "kind", stat.Kind, "objects", stat.Objects, "failedNamespaces", stat.failedNamespaces())
+		case stat.Objects == 0:
+			slog.Info("kind has no objects", "kind", stat.Kind)
+		case stat.Truncated:
+			slog.Info("kind searched partially", "kind", stat.Kind, "objects", stat.Objects)
+		default:
+			slog.Info("kind searched", "kind", stat.Kind, "objects", stat.Objects)
+		}
+	}
+
+	if a.LatestOnly {
+		slog.Info("only latest revision of deployments was searched", "skippedReplicaSets", a.staleRevisions)
+	}
+
+	slog.Info("search finished",
+		"objects", objects,
+		"searched", a.searchedObjects,
+		"matches", len(a.Matches),
+		"duration", time.Since(a.started).Round(time.Millisecond),
+	)
+}
@@ -0,0 +1,520 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMaxTotalRetries(t *testing.T) {
+	a := newTestApplication("x")
+	a.Namespace = "prod"
+	a.Retries = 3
+	a.MaxTotalRetries = 1
+
+	calls := 0
+
+	a.clientset.(*fake.Clientset).PrependReactor("list", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+
+		return true, nil, apierrors.NewServiceUnavailable("etcd is down")
+	})
+
+	initApplication(t, a)
+
+	err := a.getConfigmaps(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "max-total-retries") {
+		t.Fatalf("expected exhausted retry budget error, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected first attempt and 1 retry, got %d calls", calls)
+	}
+}
+
+func testSecret(namespace, name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       make(map[string][]byte),
+	}
+
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+
+	return secret
+}
+
+func TestSecretsDecoded(t *testing.T) {
+	for _, raw := range []bool{false, true} {
+		a := newTestApplication("password: hunter2",
+			testSecret("prod", "db", map[string]string{"password": "hunter2"}),
+		)
+		a.Raw = raw
+
+		matches := findMatches(t, a)
+
+		if got := matchedObjects(matches); !slices.Equal(got, []string{"Secrets/prod/db"}) {
+			t.Errorf("raw %v: expected decoded secret value to match, got %v", raw, got)
+		}
+
+		if strings.Contains(a.KubernetesObjects[0].Object, "aHVudGVyMg==") {
+			t.Errorf("raw %v: expected secret data without base64", raw)
+		}
+	}
+}
+
+func TestSecretsDecodedPrecise(t *testing.T) {
+	a := newTestApplication("hunter2",
+		testSecret("prod", "db", map[string]string{"password": "hunter2"}),
+	)
+	a.Precise = true
+
+	matches := findMatches(t, a)
+	if len(matches) != 1 {
+		t.Fatalf("expected decoded secret value to match with precise, got %d matches", len(matches))
+	}
+
+	if matches[0].Path != "data.password" || matches[0].Match != "hunter2" {
+		t.Errorf("expected match in data.password, got %q in %s", matches[0].Match, matches[0].Path)
+	}
+}
+
+func TestSecretsExcludedByWhere(t *testing.T) {
+	a := newTestApplication("hunter2",
+		testSecret("prod", "db", map[string]string{"password": "hunter2"}),
+	)
+	a.WhereToSearch = "pods"
+
+	if got := matchedObjects(findMatches(t, a)); len(got) != 0 {
+		t.Errorf("expected secrets not to be searched, got %v", got)
+	}
+}
+
+func TestMultipleNamespaces(t *testing.T) {
+	a := newTestApplication("nginx",
+		testPod("prod", "web", "nginx"),
+		testPod("staging", "web", "nginx"),
+		testPod("dev", "web", "nginx"),
+	)
+	a.Namespace = "prod, staging,prod"
+	a.WhereToSearch = "pods"
+
+	got := slices.Sorted(slices.Values(matchedObjects(findMatches(t, a))))
+	if want := []string{"Pods/prod/web", "Pods/staging/web"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if namespaces := a.namespaces(); !slices.Equal(namespaces, []string{"prod", "staging"}) {
+		t.Errorf("expected unique namespaces, got %v", namespaces)
+	}
+
+	a.Namespace = ""
+
+	if namespaces := a.namespaces(); !slices.Equal(namespaces, []string{metav1.NamespaceAll}) {
+		t.Errorf("expected all namespaces, got %v", namespaces)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	pages := map[string]*corev1.PodList{
+		"": {
+			ListMeta: metav1.ListMeta{Continue: "page-2"},
+			Items:    []corev1.Pod{*testPod("prod", "web-1", "nginx"), *testPod("prod", "web-2", "nginx")},
+		},
+		"page-2": {
+			ListMeta: metav1.ListMeta{Continue: "page-3"},
+			Items:    []corev1.Pod{*testPod("prod", "web-3", "nginx"), *testPod("prod", "web-4", "nginx")},
+		},
+		"page-3": {
+			Items: []corev1.Pod{*testPod("prod", "web-5", "nginx")},
+		},
+	}
+
+	a := newTestApplication("nginx")
+	a.WhereToSearch = "pods"
+	a.PageSize = 2
+
+	limits := make([]int64, 0)
+
+	a.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		limits = append(limits, opts.Limit)
+
+		return true, pages[opts.Continue], nil
+	})
+
+	got := matchedObjects(findMatches(t, a))
+	if len(got) != 5 {
+		t.Errorf("expected pods of all pages, got %v", got)
+	}
+
+	if !slices.Equal(limits, []int64{2, 2, 2}) {
+		t.Errorf("expected 3 requests with page size, got limits %v", limits)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	web := testPod("prod", "web", "nginx")
+	web.Labels = map[string]string{"app": "web"}
+
+	db := testPod("prod", "db", "nginx")
+	db.Labels = map[string]string{"app": "db"}
+
+	a := newTestApplication("nginx", web, db, testPod("dev", "web", "nginx"))
+	a.WhereToSearch = "pods"
+	a.Namespace = "prod"
+	a.Selector = "app=web"
+
+	findMatches(t, a)
+
+	if len(a.KubernetesObjects) != 1 || a.KubernetesObjects[0].Name != "web" || a.KubernetesObjects[0].Namespace != "prod" {
+		t.Errorf("expected only labeled pod to be fetched, got %v", a.KubernetesObjects)
+	}
+
+	a = newTestApplication("nginx")
+	a.Selector = "app in (web"
+
+	if err := a.Init(t.Context()); err == nil {
+		t.Error("expected invalid selector to fail Init")
+	}
+}
+
+func TestFieldSelector(t *testing.T) {
+	a := newTestApplication("nginx", testPod("prod", "web", "nginx"))
+	a.WhereToSearch = "pods,configmaps"
+	a.FieldSelector = "status.phase=Running"
+
+	var selector string
+
+	clientset := a.clientset.(*fake.Clientset)
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		selector = action.(k8stesting.ListActionImpl).ListOptions.FieldSelector
+
+		return false, nil, nil
+	})
+	// kinds which do not support field are skipped
+	clientset.PrependReactor("list", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewBadRequest(`field label not supported: status.phase`)
+	})
+
+	findMatches(t, a)
+
+	if selector != "status.phase=Running" {
+		t.Errorf("expected field selector to be passed to list, got %q", selector)
+	}
+
+	a = newTestApplication("nginx")
+	a.FieldSelector = "status.phase"
+
+	if err := a.Init(t.Context()); err == nil {
+		t.Error("expected invalid field selector to fail Init")
+	}
+}
+
+func TestKindStats(t *testing.T) {
+	a := newTestApplication("nginx",
+		testPod("prod", "web", "nginx"),
+		testPod("prod", "db", "postgres"),
+		testConfigMap("prod", "cfg", map[string]string{"image": "nginx"}),
+	)
+	a.WhereToSearch = "pods,configmaps,secrets"
+
+	writer := &recordWriter{}
+	a.ResultWriter = writer
+	initApplication(t, a)
+
+	if err := a.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := make(map[string]int)
+	for _, stat := range a.KindStats {
+		stats[stat.Kind] = stat.Objects
+	}
+
+	if want := map[string]int{"ConfigMaps": 1, "Pods": 2, "Secrets": 0}; !maps.Equal(stats, want) {
+		t.Errorf("expected objects per kind %v, got %v", want, stats)
+	}
+
+	if a.searchedObjects != 3 || len(a.Matches) != 2 || len(writer.matches) != 2 {
+		t.Errorf("expected 3 searched objects and 2 matches, got %d and %d", a.searchedObjects, len(a.Matches))
+	}
+}
+
+func TestKindStatsFailedNamespaces(t *testing.T) {
+	logs := captureLogs(t)
+
+	a := newTestApplication("nginx",
+		testPod("prod", "web", "nginx"),
+		testPod("dev", "web", "nginx"),
+		testConfigMap("prod", "cfg", map[string]string{"image": "nginx"}),
+	)
+	a.WhereToSearch = "pods,configmaps"
+	a.Namespace = "prod,dev"
+
+	// pods are forbidden only in dev, configmaps in every namespace
+	a.clientset.(*fake.Clientset).PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Resource == "configmaps" || action.GetNamespace() == "dev" {
+			return true, nil, apierrors.NewForbidden(corev1.Resource(action.GetResource().Resource), "", errors.New("rbac"))
+		}
+
+		return false, nil, nil
+	})
+
+	findMatches(t, a)
+	a.printKindStats()
+
+	for _, want := range []string{
+		`msg="kind searched partially" kind=Pods objects=1 failedNamespaces=dev`,
+		`msg="kind was not searched" kind=ConfigMaps`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected %q in logs, got %q", want, logs)
+		}
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "fails twice then succeeds",
+			retries:   3,
+			errs:      []error{apierrors.NewTooManyRequests("slow down", 0), apierrors.NewInternalError(errors.New("etcd"))},
+			wantCalls: 3,
+		},
+		{
+			name:      "retries are exhausted",
+			retries:   1,
+			errs:      []error{apierrors.NewTooManyRequests("slow down", 0), apierrors.NewInternalError(errors.New("etcd"))},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "permission error is not retried",
+			retries:   3,
+			errs:      []error{apierrors.NewForbidden(corev1.Resource("configmaps"), "", errors.New("rbac"))},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("registry.example.com",
+			testConfigMap("prod", "api", map[string]string{"image": "registry.example.com/api:1.0"}),
+		)
+		a.Namespace = "prod"
+		a.Retries = tt.retries
+		a.Strict = true
+
+		calls := 0
+
+		a.clientset.(*fake.Clientset).PrependReactor("list", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			calls++
+
+			if calls <= len(tt.errs) {
+				return true, nil, tt.errs[calls-1]
+			}
+
+			return false, nil, nil
+		})
+
+		initApplication(t, a)
+
+		err := a.getConfigmaps(t.Context())
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		if calls != tt.wantCalls {
+			t.Errorf("%s: expected %d calls, got %d", tt.name, tt.wantCalls, calls)
+		}
+
+		if !tt.wantErr && len(a.KubernetesObjects) != 1 {
+			t.Errorf("%s: expected configmap listed after retries, got %d objects", tt.name, len(a.KubernetesObjects))
+		}
+	}
+}
+
+func TestCountObjectsFullListRetried(t *testing.T) {
+	a := newTestApplication("registry.example.com",
+		testConfigMap("prod", "api", nil),
+		testConfigMap("prod", "web", nil),
+		testConfigMap("prod", "db", nil),
+	)
+	a.Namespace = "prod"
+	a.CountObjects = true
+	a.Retries = 3
+	a.Strict = true
+
+	fullLists := 0
+
+	// first page does not report remaining items, full list fails once
+	a.clientset.(*fake.Clientset).PrependReactor("list", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		if opts.Limit == 1 {
+			return true, &corev1.ConfigMapList{
+				ListMeta: metav1.ListMeta{Continue: "page-2"},
+				Items:    []corev1.ConfigMap{*testConfigMap("prod", "api", nil)},
+			}, nil
+		}
+
+		fullLists++
+		if fullLists == 1 {
+			return true, nil, apierrors.NewTooManyRequests("slow down", 0)
+		}
+
+		return false, nil, nil
+	})
+
+	initApplication(t, a)
+
+	if err := a.getConfigmaps(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if fullLists != 2 {
+		t.Errorf("expected full list retried once, got %d full lists", fullLists)
+	}
+
+	if len(a.KindStats) != 1 || a.KindStats[0].Objects != 3 {
+		t.Errorf("expected 3 configmaps counted, got %+v", a.KindStats)
+	}
+}
+
+func TestSince(t *testing.T) {
+	created := func(obj metav1.Object, age time.Duration) {
+		obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	}
+
+	recent := testPod("prod", "recent", "nginx")
+	created(recent, 10*time.Minute)
+
+	old := testPod("prod", "old", "nginx")
+	created(old, 48*time.Hour)
+
+	// old event seen again recently
+	event := &corev1.Event{
+		ObjectMeta:    metav1.ObjectMeta{Name: "web.1", Namespace: "prod"},
+		Message:       "Pulling image nginx",
+		LastTimestamp: metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+	}
+	created(event, 48*time.Hour)
+
+	staleEvent := &corev1.Event{
+		ObjectMeta:    metav1.ObjectMeta{Name: "web.2", Namespace: "prod"},
+		Message:       "Pulling image nginx",
+		LastTimestamp: metav1.NewTime(time.Now().Add(-3 * time.Hour)),
+	}
+	created(staleEvent, 10*time.Minute)
+
+	a := newTestApplication("nginx", recent, old, event, staleEvent)
+	a.Since = time.Hour
+
+	got := slices.Compact(slices.Sorted(slices.Values(matchedObjects(findMatches(t, a)))))
+	if want := []string{"Events/prod/web.1", "Pods/prod/recent"}; !slices.Equal(got, want) {
+		t.Errorf("expected only recent objects %v, got %v", want, got)
+	}
+}
+
+func TestListedOnce(t *testing.T) {
+	a := newTestApplication("nginx")
+	a.Namespace = "prod,staging,prod"
+	a.WhereToSearch = "pods,po,pod"
+
+	listed := 0
+
+	// every list request returns the same pod as if namespaces overlapped
+	a.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		listed++
+
+		return true, &corev1.PodList{Items: []corev1.Pod{*testPod("prod", "web", "nginx")}}, nil
+	})
+
+	if got := matchedObjects(findMatches(t, a)); !slices.Equal(got, []string{"Pods/prod/web"}) {
+		t.Errorf("expected pod searched once, got %v", got)
+	}
+
+	if listed != 2 {
+		t.Errorf("expected each namespace listed once, got %d list requests", listed)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	logs := captureLogs(t)
+
+	interval := progressInterval
+	progressInterval = 100 * time.Millisecond
+
+	t.Cleanup(func() { progressInterval = interval })
+
+	pages := map[string]*corev1.PodList{
+		"": {
+			ListMeta: metav1.ListMeta{Continue: "page-2"},
+			Items:    []corev1.Pod{*testPod("prod", "web-1", "nginx"), *testPod("prod", "web-2", "nginx")},
+		},
+		"page-2": {
+			ListMeta: metav1.ListMeta{Continue: "page-3"},
+			Items:    []corev1.Pod{*testPod("prod", "web-3", "nginx"), *testPod("prod", "web-4", "nginx")},
+		},
+		"page-3": {
+			Items: []corev1.Pod{*testPod("prod", "web-5", "nginx")},
+		},
+	}
+
+	a := newTestApplication("nginx")
+	a.WhereToSearch = "pods"
+	a.PageSize = 2
+	a.Progress = true
+
+	a.clientset.(*fake.Clientset).PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+
+		// only second page is slower than progress interval
+		if opts.Continue == "page-2" {
+			time.Sleep(progressInterval + progressInterval/2)
+		}
+
+		return true, pages[opts.Continue], nil
+	})
+
+	initApplication(t, a)
+
+	if a.progress {
+		t.Fatal("expected progress disabled when stderr is not terminal")
+	}
+
+	a.progress = true
+
+	if _, err := a.FindMatches(t.Context()); err != nil {
+		t.Fatalf("FindMatches: %v", err)
+	}
+
+	got := make([]string, 0)
+
+	for line := range strings.Lines(logs.String()) {
+		if _, progress, ok := strings.Cut(line, `msg="fetching Pods ..." `); ok {
+			got = append(got, strings.TrimSpace(progress))
+		}
+	}
+
+	if want := []string{"objects=4"}; !slices.Equal(got, want) {
+		t.Errorf("expected progress %v after slow page only, got %v", want, got)
+	}
+}
@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	WhereLocal   = "local"
+	WhereCluster = "cluster"
+)
+
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+// loadManifests decodes all objects from manifests in dir.
+func loadManifests(dir string) ([]runtime.Object, error) {
+	objects := make([]runtime.Object, 0)
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !slices.Contains(manifestExtensions, filepath.Ext(path)) {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return errors.Wrap(err, "error in os.Open")
+		}
+		defer file.Close()
+
+		fileObjects, err := decodeManifests(file)
+		if err != nil {
+			return errors.Wrap(err, "error in decoding "+path)
+		}
+
+		objects = append(objects, fileObjects...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// localManifests decodes objects from -path, or from stdin when path is empty or "-".
+func (a *Application) localManifests() ([]runtime.Object, error) {
+	if a.Path == "" || a.Path == "-" {
+		objects, err := decodeManifests(os.Stdin)
+		if err != nil {
+			return nil, errors.Wrap(err, "error in decoding stdin")
+		}
+
+		return objects, nil
+	}
+
+	objects, err := loadManifests(a.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in loadManifests")
+	}
+
+	return objects, nil
+}
+
+// decodeManifests decodes multi-document YAML or JSON stream.
+func decodeManifests(r io.Reader) ([]runtime.Object, error) {
+	objects := make([]runtime.Object, 0)
+
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	decoder := scheme.Codecs.UniversalDeserializer()
+
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "error in reading document")
+		}
+
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+
+		documentObjects, err := decodeDocument(decoder, document)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, documentObjects...)
+	}
+
+	return objects, nil
+}
+
+// decodeDocument decodes one document, List documents are expanded into their items.
+func decodeDocument(decoder runtime.Decoder, document []byte) ([]runtime.Object, error) {
+	object, _, err := decoder.Decode(document, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		// custom resources are kept unstructured for -gvr
+		object, err = decodeUnstructured(document)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error in decoding document")
+	}
+
+	if !meta.IsListType(object) {
+		return []runtime.Object{object}, nil
+	}
+
+	items, err := meta.ExtractList(object)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in meta.ExtractList")
+	}
+
+	objects := make([]runtime.Object, 0, len(items))
+
+	for _, item := range items {
+		// items of v1.List are left raw
+		if unknown, ok := item.(*runtime.Unknown); ok {
+			itemObjects, err := decodeDocument(decoder, unknown.Raw)
+			if err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, itemObjects...)
+
+			continue
+		}
+
+		objects = append(objects, item)
+	}
+
+	return objects, nil
+}
+
+// uniqueObjects drops objects with the same kind, namespace and name as an earlier one.
+func uniqueObjects(objects []runtime.Object) []runtime.Object {
+	seen := make(map[string]bool, len(objects))
+	unique := make([]runtime.Object, 0, len(objects))
+
+	for _, object := range objects {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			unique = append(unique, object)
+
+			continue
+		}
+
+		gvk := object.GetObjectKind().GroupVersionKind()
+		key := gvk.String() + "/" + accessor.GetNamespace() + "/" + accessor.GetName()
+
+		if seen[key] {
+			slog.Warn("skipping duplicate manifest", "kind", gvk.Kind, "namespace", accessor.GetNamespace(), "name", accessor.GetName())
+
+			continue
+		}
+
+		seen[key] = true
+		unique = append(unique, object)
+	}
+
+	return unique
+}
+
+func decodeUnstructured(document []byte) (runtime.Object, error) {
+	data, err := yaml.ToJSON(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in yaml.ToJSON")
+	}
+
+	object, _, err := unstructured.UnstructuredJSONScheme.Decode(data, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error in UnstructuredJSONScheme.Decode")
+	}
+
+	return object, nil
+}
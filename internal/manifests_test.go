@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testConfigMapManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bar
+  namespace: default
+data:
+  url: postgres://db
+`
+
+const testListManifest = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: baz
+    namespace: default
+  data:
+    url: postgres://replica
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web
+    namespace: default
+  spec:
+    containers:
+    - name: app
+      image: postgres
+`
+
+func writeManifests(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestDecodeManifestsExpandsList(t *testing.T) {
+	dir := writeManifests(t, map[string]string{"list.yaml": testListManifest})
+
+	objects, err := loadManifests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("expected 2 objects from List, got %d", len(objects))
+	}
+}
+
+func TestFakeFromDirDuplicatesAndList(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"a.yaml":    testConfigMapManifest,
+		"b.yaml":    testConfigMapManifest,
+		"list.yaml": testListManifest,
+	})
+
+	a := newTestApplication("postgres")
+	a.clientset = nil
+	a.FakeFromDir = dir
+
+	got := matchedObjects(findMatches(t, a))
+	want := map[string]bool{
+		"ConfigMaps/default/bar": true,
+		"ConfigMaps/default/baz": true,
+		"Pods/default/web":       true,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d matches, got %v", len(want), got)
+	}
+
+	for _, object := range got {
+		if !want[object] {
+			t.Errorf("unexpected match %s", object)
+		}
+	}
+}
+
+func TestLocalManifestsDuplicates(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"a.yaml": testConfigMapManifest,
+		"b.yaml": testConfigMapManifest,
+	})
+
+	a := newTestApplication("postgres")
+	a.clientset = nil
+	a.WhereToSearch = WhereLocal
+	a.Path = dir
+
+	if got := matchedObjects(findMatches(t, a)); len(got) != 1 {
+		t.Fatalf("expected 1 match, got %v", got)
+	}
+}
+
+func TestLocalManifests(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"config.yaml": testConfigMapManifest,
+		"pod.json":    `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web", "namespace": "default"}, "spec": {"containers": [{"name": "app", "image": "nginx"}]}}`,
+		"README.md":   "postgres is not searched in other files",
+	})
+
+	a := newTestApplication("postgres|nginx")
+	a.clientset = nil
+	a.WhereToSearch = WhereLocal
+	a.Path = dir
+	// local manifests are searched without cluster
+	a.Kubeconfig = filepath.Join(dir, "missing")
+
+	got := slices.Compact(matchedObjects(findMatches(t, a)))
+	if want := []string{"ConfigMaps/default/bar", "Pods/default/web"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if a.clusterContext != WhereLocal {
+		t.Errorf("expected local context, got %q", a.clusterContext)
+	}
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+const (
+	OutputText      = "text"
+	OutputJSON      = "json"
+	OutputNDJSON    = "ndjson"
+	OutputYAML      = "yaml"
+	OutputCSV       = "csv"
+	OutputTemplate  = "template"
+	OutputCompact   = "compact"
+	OutputHistogram = "histogram"
+	OutputSummary   = "summary-only"
+	OutputName      = "name"
+	OutputObjects   = "objects"
+)
+
+var outputFormats = []string{
+	OutputText,
+	OutputJSON,
+	OutputNDJSON,
+	OutputYAML,
+	OutputCSV,
+	OutputTemplate,
+	OutputCompact,
+	OutputHistogram,
+	OutputSummary,
+	OutputName,
+	OutputObjects,
+}
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var colorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+const unlabeledApp = "(unlabeled)"
+
+var shortKinds = map[string]string{
+	"Pods":                     "po",
+	"ConfigMaps":               "cm",
+	"Secrets":                  "secret",
+	"Deployments":              "deploy",
+	"StatefulSets":             "sts",
+	"DaemonSets":               "ds",
+	"ReplicaSets":              "rs",
+	"Jobs":                     "job",
+	"CronJobs":                 "cj",
+	"Services":                 "svc",
+	"Ingresses":                "ing",
+	"NetworkPolicies":          "netpol",
+	"PersistentVolumeClaims":   "pvc",
+	"PersistentVolumes":        "pv",
+	"HorizontalPodAutoscalers": "hpa",
+	"PodDisruptionBudgets":     "pdb",
+	"ServiceAccounts":          "sa",
+	"Events":                   "ev",
+	"Namespaces":               "ns",
+	"ComponentStatuses":        "cs",
+}
+
+func shortKind(kind string) string {
+	if short, ok := shortKinds[kind]; ok {
+		return short
+	}
+
+	return strings.ToLower(kind)
+}
+
+func (a *Application) newResultWriter(w io.Writer) ResultWriter {
+	if a.Quiet {
+		return DiscardWriter{}
+	}
+
+	if a.Count {
+		return NewCountWriter(w)
+	}
+
+	switch a.Output {
+	case OutputJSON:
+		return NewJSONWriter(w, a.Pretty)
+	case OutputNDJSON:
+		return NewNDJSONWriter(w)
+	case OutputYAML:
+		return NewYAMLWriter(w)
+	case OutputCSV:
+		return NewCSVWriter(w, a.delimiter)
+	case OutputCompact:
+		return NewCompactWriter(w)
+	case OutputHistogram:
+		return NewHistogramWriter(w)
+	case OutputName:
+		return NewNameWriter(w)
+	case OutputObjects:
+		return NewObjectsWriter(w, a.engine.redact)
+	case OutputSummary:
+		return NewSummaryWriter(w, a.started)
+	case OutputTemplate:
+		// template file controls line endings itself
+		return NewTemplateWriter(w, a.template, a.TemplateFile == "")
+	default:
+		// text output is logged, to file it is logged without time
+		logger := slog.Default()
+
+		switch {
+		case a.outputFile != nil:
+			logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{ReplaceAttr: withoutTime}))
+		case a.LogLevel > slog.LevelInfo:
+			// results are printed even when -log-level hides info logs
+			logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+		}
+
+		if a.GroupByApp {
+			return NewAppGroupWriter(logger)
+		}
+
+		return NewTextWriter(logger, a.ShortKind)
+	}
+}
+
+// useColor reports whether matched text is highlighted, only text output
+// is colored, in auto mode when it is logged to terminal and NO_COLOR is not set.
+func (a *Application) useColor() bool {
+	if a.Output != OutputText {
+		return false
+	}
+
+	switch a.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return a.outputFile == nil && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// resultWriter returns ResultWriter set by caller or one for -output.
+func (a *Application) resultWriter(w io.Writer) ResultWriter {
+	if a.ResultWriter != nil {
+		return a.ResultWriter
+	}
+
+	return a.newResultWriter(w)
+}
+
+// parseDelimiter returns csv delimiter, \t can be used for tab.
+func parseDelimiter(value string) (rune, error) {
+	if value == `\t` {
+		return '\t', nil
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || delimiter == utf8.RuneError || strings.ContainsRune("\"\r\n", delimiter) {
+		return 0, errors.Errorf("delimiter %q must be one character except quote and newline", value)
+	}
+
+	return delimiter, nil
+}
+
+func withoutTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+
+	return attr
+}
+
+func (a *Application) printMatches(writer ResultWriter) error {
+	for _, match := range a.Matches {
+		if err := writer.Write(match); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+func (a *Application) initTemplate() error {
+	var err error
+
+	switch {
+	case a.TemplateFile != "":
+		a.template, err = template.ParseFiles(a.TemplateFile)
+	case a.Template != "":
+		a.template, err = template.New("output").Parse(a.Template)
+	default:
+		return errors.New("template or template-file is required for template output")
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "error in template.Parse")
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestOutputFile(t *testing.T) {
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{OutputText, []string{"name=api", "image: registry.example.com/api:1.0"}},
+		{OutputName, []string{"pods/prod/api\n"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("registry.example.com",
+			testPod("prod", "api", "registry.example.com/api:1.0"),
+			testPod("prod", "web", "nginx:1.27"),
+		)
+		a.ResultWriter = nil
+		a.Output = tt.output
+		a.OutputFile = filepath.Join(t.TempDir(), "matches.txt")
+
+		initApplication(t, a)
+
+		if err := a.Run(t.Context()); err != nil {
+			t.Fatalf("%s: Run: %v", tt.output, err)
+		}
+
+		data, err := os.ReadFile(a.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s: expected %q in output-file, got %q", tt.output, want, data)
+			}
+		}
+
+		if strings.Contains(string(data), "web") {
+			t.Errorf("%s: not matching pod in output-file: %q", tt.output, data)
+		}
+	}
+}
+
+func TestOutputFileCreateError(t *testing.T) {
+	a := newTestApplication("registry.example.com")
+	a.OutputFile = filepath.Join(t.TempDir(), "missing", "matches.txt")
+
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Init(t.Context()); err == nil {
+		t.Fatal("expected error creating output-file in missing directory")
+	}
+}
+
+func TestOutputObjects(t *testing.T) {
+	a := newTestApplication("registry.example.com",
+		testPod("prod", "api", "registry.example.com/api:1.0"),
+		testPod("prod", "web", "nginx:1.27"),
+		testConfigMap("prod", "images", map[string]string{
+			"api": "registry.example.com/api:1.0",
+			"web": "registry.example.com/web:1.0",
+		}),
+	)
+	a.ResultWriter = nil
+	a.Output = OutputObjects
+	a.OutputFile = filepath.Join(t.TempDir(), "objects.yaml")
+	a.Sort = true
+
+	initApplication(t, a)
+
+	if err := a.Run(t.Context()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	file, err := os.Open(a.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+	got := make([]string, 0)
+
+	for {
+		var object unstructured.Unstructured
+
+		err := decoder.Decode(&object.Object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("output is not yaml: %v", err)
+		}
+
+		got = append(got, object.GetAPIVersion()+"/"+object.GetKind()+"/"+object.GetNamespace()+"/"+object.GetName())
+	}
+
+	// configmap matched twice is written once
+	if want := []string{"v1/ConfigMap/prod/images", "v1/Pod/prod/api"}; !slices.Equal(got, want) {
+		t.Errorf("expected documents %v, got %v", want, got)
+	}
+}
+
+func TestOutputCSV(t *testing.T) {
+	for _, delimiter := range []string{",", `\t`} {
+		a := newTestApplication("token",
+			testConfigMap("prod", "auth", map[string]string{"header": `token="a,b"	end`}),
+		)
+		a.ResultWriter = nil
+		a.Output = OutputCSV
+		a.Delimiter = delimiter
+		a.OutputFile = filepath.Join(t.TempDir(), "matches.csv")
+
+		initApplication(t, a)
+
+		if err := a.Run(t.Context()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+
+		file, err := os.Open(a.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader := csv.NewReader(file)
+		reader.Comma = a.delimiter
+
+		records, err := reader.ReadAll()
+		file.Close()
+
+		if err != nil {
+			t.Fatalf("%q: output is not csv: %v", delimiter, err)
+		}
+
+		if len(records) != 2 {
+			t.Fatalf("%q: expected header and 1 row, got %d records", delimiter, len(records))
+		}
+
+		header, row := records[0], records[1]
+		if !slices.Equal(header, csvHeader) || len(row) != len(header) {
+			t.Fatalf("%q: expected %d columns, got header %v and row %v", delimiter, len(csvHeader), header, row)
+		}
+
+		if row[0] != "ConfigMaps" || row[1] != "prod" || row[2] != "auth" || row[5] != "token" {
+			t.Errorf("%q: unexpected row %v", delimiter, row)
+		}
+
+		// yaml escapes quotes in value, csv must keep them
+		if !strings.Contains(row[4], `header: "token=\"a,b\"`) {
+			t.Errorf("%q: expected snippet with quote and delimiter, got %q", delimiter, row[4])
+		}
+	}
+}
+
+func TestColorOnlyTextOutput(t *testing.T) {
+	for _, output := range outputFormats {
+		if output == OutputText {
+			continue
+		}
+
+		a := newTestApplication("registry.example.com", testPod("prod", "api", "registry.example.com/api:1.0"))
+		a.ResultWriter = nil
+		a.Output = output
+		a.Color = ColorAlways
+		a.Template = "{{.Text}}"
+		a.OutputFile = filepath.Join(t.TempDir(), "matches")
+
+		initApplication(t, a)
+
+		if err := a.Run(t.Context()); err != nil {
+			t.Fatalf("%s: Run: %v", output, err)
+		}
+
+		data, err := os.ReadFile(a.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// json escapes control characters
+		if len(data) == 0 || strings.Contains(string(data), "\x1b[") || strings.Contains(string(data), `\u001b[`) {
+			t.Errorf("%s: expected output without escape codes, got %q", output, data)
+		}
+	}
+}
+
+func TestLogLevelKeepsResults(t *testing.T) {
+	var logs bytes.Buffer
+
+	// like -log-level=warn in main
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	osStderr := os.Stderr
+	os.Stderr = stderr
+
+	t.Cleanup(func() { os.Stderr = osStderr })
+
+	a := newTestApplication("registry.example.com", testPod("prod", "api", "registry.example.com/api:1.0"))
+	a.LogLevel = slog.LevelWarn
+
+	matches := findMatches(t, a)
+
+	writeAll(t, a.newResultWriter(os.Stdout), matches...)
+
+	os.Stderr = osStderr
+
+	if strings.Contains(logs.String(), "Getting Pods") {
+		t.Errorf("expected progress logs suppressed, got %q", logs.String())
+	}
+
+	results, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(results), "level=INFO") || !strings.Contains(string(results), "name=api") {
+		t.Errorf("expected result printed at warn log level, got %q", results)
+	}
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// missingOwner returns first owner reference of object that points to
+// object that does not exist anymore, or nil if all owners are present.
+func (a *Application) missingOwner(ctx context.Context, obj KubernetesObject) (*metav1.OwnerReference, error) {
+	for _, ref := range obj.OwnerReferences {
+		exists, err := a.ownerExists(ctx, obj.Namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return &ref, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (a *Application) ownerExists(ctx context.Context, namespace string, ref metav1.OwnerReference) (bool, error) {
+	// objects are searched concurrently by workers
+	a.mu.Lock()
+	exists, ok := a.owners[ref.UID]
+	a.mu.Unlock()
+
+	if ok {
+		return exists, nil
+	}
+
+	uid, err := a.getOwnerUID(ctx, namespace, ref)
+	if apierrors.IsNotFound(err) {
+		uid, err = "", nil
+	}
+
+	if err != nil {
+		return false, errors.Wrap(err, "error in getting owner "+ref.Kind+"/"+ref.Name)
+	}
+
+	// owner can be recreated with the same name
+	exists = uid == ref.UID
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.owners == nil {
+		a.owners = make(map[types.UID]bool)
+	}
+
+	a.owners[ref.UID] = exists
+
+	return exists, nil
+}
+
+func (a *Application) getOwnerUID(ctx context.Context, namespace string, ref metav1.OwnerReference) (types.UID, error) {
+	var (
+		object metav1.Object
+		err    error
+	)
+
+	switch ref.Kind {
+	case "ReplicaSet":
+		object, err = a.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Deployment":
+		object, err = a.clientset.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "StatefulSet":
+		object, err = a.clientset.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "DaemonSet":
+		object, err = a.clientset.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Job":
+		object, err = a.clientset.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "CronJob":
+		object, err = a.clientset.BatchV1().CronJobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	case "Node":
+		object, err = a.clientset.CoreV1().Nodes().Get(ctx, ref.Name, metav1.GetOptions{})
+	default:
+		slog.Debug("can not check owner, assuming it exists", "kind", ref.Kind, "name", ref.Name)
+
+		return ref.UID, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return object.GetUID(), nil
+}
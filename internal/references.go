@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// referenceSources are keys of volume sources and env references
+// which point to objects of kind in -references.
+var referenceSources = map[string][]string{
+	"configmaps": {"configMap", "configMapRef", "configMapKeyRef"},
+	"secrets":    {"secret", "secretRef", "secretKeyRef"},
+}
+
+// parseReference parses -references in form kind/name.
+func parseReference(value string) (string, string, error) {
+	kind, name, ok := strings.Cut(value, "/")
+	if !ok || name == "" {
+		return "", "", errors.Errorf("references %q must be in form kind/name", value)
+	}
+
+	kind = resolveKind(kind)
+
+	if _, ok := referenceSources[kind]; !ok {
+		return "", "", errors.Errorf("references supports only configmaps and secrets, got %q", kind)
+	}
+
+	return kind, name, nil
+}
+
+// referenceFields returns names of objects of kind referenced by volumes
+// and environment variables of pod or workload template.
+func referenceFields(obj runtime.Object, kind string) []field {
+	fields := slices.Concat(volumeFields(obj), envFields(obj))
+
+	return slices.DeleteFunc(fields, func(f field) bool {
+		if len(f.Path) < 2 {
+			return true
+		}
+
+		source, _ := f.Path[len(f.Path)-2].(string)
+		key, _ := f.Path[len(f.Path)-1].(string)
+
+		return !slices.Contains(referenceSources[kind], source) || key == "key"
+	})
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// isStaleRevision reports whether object is ReplicaSet of deployment
+// revision that is not current, template of such ReplicaSet is not used.
+func (a *Application) isStaleRevision(ctx context.Context, obj KubernetesObject) (bool, error) {
+	if obj.Kind != "ReplicaSets" {
+		return false, nil
+	}
+
+	ref := metav1.GetControllerOfNoCopy(&metav1.ObjectMeta{OwnerReferences: obj.OwnerReferences})
+	if ref == nil || ref.Kind != "Deployment" {
+		return false, nil
+	}
+
+	revision, err := a.deploymentRevision(ctx, obj.Namespace, *ref)
+	if err != nil {
+		return false, err
+	}
+
+	// deleted deployment has no current revision
+	if revision == "" || obj.Annotations[revisionAnnotation] == revision {
+		return false, nil
+	}
+
+	slog.Debug("skipping old revision",
+		"name", obj.Name,
+		"namespace", obj.Namespace,
+		"revision", obj.Annotations[revisionAnnotation],
+		"deployment", ref.Name,
+		"current", revision,
+	)
+
+	a.mu.Lock()
+	a.staleRevisions++
+	a.mu.Unlock()
+
+	return true, nil
+}
+
+// deploymentRevision returns current revision of deployment, revisions
+// are cached because deployment has many ReplicaSets.
+func (a *Application) deploymentRevision(ctx context.Context, namespace string, ref metav1.OwnerReference) (string, error) {
+	// objects are searched concurrently by workers
+	a.mu.Lock()
+	revision, ok := a.revisions[ref.UID]
+	a.mu.Unlock()
+
+	if ok {
+		return revision, nil
+	}
+
+	deployment, err := a.clientset.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		revision = ""
+	case err != nil:
+		return "", errors.Wrap(err, "error in getting deployment "+ref.Name)
+	case deployment.UID == ref.UID:
+		revision = deployment.Annotations[revisionAnnotation]
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.revisions == nil {
+		a.revisions = make(map[types.UID]string)
+	}
+
+	a.revisions[ref.UID] = revision
+
+	return revision, nil
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testRevisionObjects() []runtime.Object {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "prod",
+			UID:         "web-uid",
+			Annotations: map[string]string{revisionAnnotation: "3"},
+		},
+	}
+
+	controller := true
+
+	replicaSet := func(name, revision string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "prod",
+				Annotations: map[string]string{revisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "web",
+					UID:        "web-uid",
+					Controller: &controller,
+				}},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Image: "nginx:" + revision}},
+					},
+				},
+			},
+		}
+	}
+
+	return []runtime.Object{deployment, replicaSet("web-old", "2"), replicaSet("web-new", "3")}
+}
+
+func TestLatestOnly(t *testing.T) {
+	tests := []struct {
+		latestOnly bool
+		want       []string
+	}{
+		{false, []string{"ReplicaSets/prod/web-new", "ReplicaSets/prod/web-old"}},
+		{true, []string{"ReplicaSets/prod/web-new"}},
+	}
+
+	for _, tt := range tests {
+		a := newTestApplication("nginx", testRevisionObjects()...)
+		a.WhereToSearch = "replicasets"
+		a.LatestOnly = tt.latestOnly
+
+		got := slices.Compact(slices.Sorted(slices.Values(matchedObjects(findMatches(t, a)))))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("latest-only %v: expected %v, got %v", tt.latestOnly, tt.want, got)
+		}
+
+		if tt.latestOnly && a.staleRevisions != 1 {
+			t.Errorf("expected 1 skipped revision, got %d", a.staleRevisions)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	goruntime "runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// searchPattern is regex heavy pattern like ones used to find leaked credentials.
+const searchPattern = `(?:password|token|secret)\s*[=:]\s*[a-z0-9]{8,}`
+
+// newSearchApplication returns application with count fetched configmaps,
+// every tenth of them has credential in it.
+func newSearchApplication(t testing.TB, workers, count int) *Application {
+	t.Helper()
+
+	objects := make([]runtime.Object, 0, count)
+
+	for i := range cap(objects) {
+		data := map[string]string{
+			"config.yaml": strings.Repeat("log: level=info format=json output=stdout\n", 20),
+		}
+
+		if i%10 == 0 {
+			data["auth"] = fmt.Sprintf("token=%08d", i)
+		}
+
+		objects = append(objects, testConfigMap("prod", fmt.Sprintf("config-%04d", i), data))
+	}
+
+	a := newTestApplication(searchPattern, objects...)
+	a.WhereToSearch = "configmaps"
+	a.Workers = workers
+
+	initApplication(t, a)
+
+	if err := a.fetch(t.Context()); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	return a
+}
+
+func TestSearchWorkers(t *testing.T) {
+	serial := newSearchApplication(t, 1, 300)
+	if err := serial.search(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(serial.Matches) != 30 {
+		t.Fatalf("expected 30 matches, got %d", len(serial.Matches))
+	}
+
+	for _, workers := range []int{2, 8, goruntime.GOMAXPROCS(0)} {
+		parallel := newSearchApplication(t, workers, 300)
+		if err := parallel.search(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(serial.Matches, parallel.Matches) {
+			t.Errorf("%d workers: matches differ from serial search", workers)
+		}
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	for _, workers := range slices.Compact([]int{1, goruntime.GOMAXPROCS(0)}) {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			a := newSearchApplication(b, workers, 1000)
+
+			for b.Loop() {
+				a.Matches = nil
+
+				if err := a.search(b.Context()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// streamBuffer is number of fetched objects waiting to be searched.
+const streamBuffer = 100
+
+// isBuffered reports whether all objects must be fetched before search,
+// ranking and sorting need all matches before first one is printed.
+func (a *Application) isBuffered() bool {
+	return a.Buffered || a.Rank || a.Sort
+}
+
+// stream searches objects while they are fetched and prints matches as they are found.
+func (a *Application) stream(ctx context.Context, writer ResultWriter) error {
+	a.objects = make(chan KubernetesObject, streamBuffer)
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	group.Go(func() error {
+		defer close(a.objects)
+
+		return a.fetch(groupCtx)
+	})
+
+	// workers search objects concurrently, matches of one object
+	// are written together
+	var mu sync.Mutex
+
+	for range a.Workers {
+		group.Go(func() error {
+			for obj := range a.objects {
+				matches, err := a.searchObject(groupCtx, obj)
+				if err != nil {
+					return err
+				}
+
+				if err := a.writeMatches(writer, &mu, matches); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	err := group.Wait()
+
+	// matches found before cancellation are printed anyway
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return err
+}
+
+func (a *Application) writeMatches(writer ResultWriter, mu *sync.Mutex, matches []Match) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	a.searchedObjects++
+
+	for _, match := range matches {
+		if err := writer.Write(match); err != nil {
+			return err
+		}
+	}
+
+	a.Matches = append(a.Matches, matches...)
+
+	return nil
+}
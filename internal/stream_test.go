@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestStreamAndBuffered(t *testing.T) {
+	objects := []runtime.Object{
+		testPod("prod", "web", "nginx"),
+		testPod("dev", "web", "nginx"),
+		testConfigMap("prod", "cfg", map[string]string{"image": "nginx"}),
+		testConfigMap("prod", "other", map[string]string{"image": "redis"}),
+	}
+
+	run := func(buffered bool) []string {
+		a := newTestApplication("nginx", objects...)
+		a.Buffered = buffered
+
+		writer := &recordWriter{}
+		a.ResultWriter = writer
+
+		initApplication(t, a)
+
+		if err := a.Run(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(writer.matches) != len(a.Matches) {
+			t.Errorf("buffered %v: expected every match to be written, got %d of %d", buffered, len(writer.matches), len(a.Matches))
+		}
+
+		return matchedObjects(writer.matches)
+	}
+
+	buffered := run(true)
+
+	// buffered matches are in stable order of kinds
+	want := []string{"ConfigMaps/prod/cfg", "Pods/prod/web", "Pods/dev/web"}
+	if got := slices.Compact(buffered); !slices.Equal(got, want) && !slices.Equal(got, []string{want[0], want[2], want[1]}) {
+		t.Errorf("buffered: expected %v, got %v", want, got)
+	}
+
+	// streamed matches are the same, in order objects were fetched
+	streamed := run(false)
+	if !slices.Equal(slices.Sorted(slices.Values(streamed)), slices.Sorted(slices.Values(buffered))) {
+		t.Errorf("expected the same matches streamed and buffered, got %v and %v", streamed, buffered)
+	}
+}
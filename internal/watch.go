@@ -0,0 +1,348 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// watchResources are resources of kinds which can be watched,
+// ComponentStatuses do not support watch.
+var watchResources = map[string]schema.GroupVersionResource{
+	"Pods":                     {Version: "v1", Resource: "pods"},
+	"ConfigMaps":               {Version: "v1", Resource: "configmaps"},
+	"Secrets":                  {Version: "v1", Resource: "secrets"},
+	"Services":                 {Version: "v1", Resource: "services"},
+	"ServiceAccounts":          {Version: "v1", Resource: "serviceaccounts"},
+	"Events":                   {Version: "v1", Resource: "events"},
+	"PersistentVolumeClaims":   {Version: "v1", Resource: "persistentvolumeclaims"},
+	"PersistentVolumes":        {Version: "v1", Resource: "persistentvolumes"},
+	"Namespaces":               {Version: "v1", Resource: "namespaces"},
+	"Deployments":              {Group: "apps", Version: "v1", Resource: "deployments"},
+	"StatefulSets":             {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSets":               {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"ReplicaSets":              {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"Jobs":                     {Group: "batch", Version: "v1", Resource: "jobs"},
+	"CronJobs":                 {Group: "batch", Version: "v1", Resource: "cronjobs"},
+	"Ingresses":                {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	"NetworkPolicies":          {Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+	"EndpointSlices":           {Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"},
+	"HorizontalPodAutoscalers": {Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"},
+	"PodDisruptionBudgets":     {Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"},
+	"Roles":                    {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+	"RoleBindings":             {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+	"ClusterRoles":             {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+	"ClusterRoleBindings":      {Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+}
+
+var clusterScopedKinds = []string{"PersistentVolumes", "Namespaces", "ClusterRoles", "ClusterRoleBindings"}
+
+// watchOutputs are outputs which print every match when it is written.
+var watchOutputs = []string{OutputText, OutputNDJSON, OutputYAML, OutputCSV, OutputTemplate, OutputName, OutputObjects}
+
+type watchedKind struct {
+	typeOf     string
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+func (a *Application) validateWatch() error {
+	if !a.Watch {
+		if a.WatchDebounce > 0 {
+			return errors.New("watch-debounce can be used only with watch")
+		}
+
+		return nil
+	}
+
+	switch {
+	case a.isWhere(WhereLocal):
+		return errors.New("watch can not be used with local manifests")
+	case a.CountObjects || a.DryRun || a.Count:
+		return errors.New("watch can not be used with count-objects, count or dry-run")
+	case a.Webhook != "":
+		return errors.New("watch can not be used with webhook")
+	case a.GroupByApp || !slices.Contains(watchOutputs, a.Output):
+		return errors.Errorf("watch supports only %v outputs", watchOutputs)
+	}
+
+	return nil
+}
+
+// watchedKinds returns kinds left by -where and -except-kind.
+func (a *Application) watchedKinds() []watchedKind {
+	kinds := make([]watchedKind, 0)
+
+	for _, typeOf := range slices.Sorted(maps.Keys(watchResources)) {
+		if !a.isInWhere(typeOf) || a.isExceptKind(typeOf) {
+			continue
+		}
+
+		kinds = append(kinds, watchedKind{
+			typeOf:     typeOf,
+			gvr:        watchResources[typeOf],
+			namespaced: !slices.Contains(clusterScopedKinds, typeOf),
+		})
+	}
+
+	if a.gvr != nil {
+		typeOf := a.gvr.GroupResource().String()
+
+		if a.isInWhere(typeOf) && !a.isExceptKind(typeOf) {
+			kinds = append(kinds, watchedKind{
+				typeOf:     typeOf,
+				gvr:        *a.gvr,
+				namespaced: a.isNamespaced(*a.gvr),
+			})
+		}
+	}
+
+	return kinds
+}
+
+// watch searches objects added or modified after first search
+// and prints matches until ctx is done.
+func (a *Application) watch(ctx context.Context, writer ResultWriter) error {
+	objects := make(chan KubernetesObject, streamBuffer)
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	for _, kind := range a.watchedKinds() {
+		namespaces := []string{""}
+		if kind.namespaced {
+			namespaces = a.namespaces()
+		}
+
+		for _, namespace := range namespaces {
+			group.Go(func() error {
+				return a.watchKind(groupCtx, kind, namespace, objects)
+			})
+		}
+	}
+
+	slog.Info("watching for changes ...")
+
+	group.Go(func() error {
+		return a.searchWatched(groupCtx, writer, objects)
+	})
+
+	err := group.Wait()
+
+	// interrupted or timed out watch is not an error
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// searchWatched searches objects from watches, with -watch-debounce
+// changes of the same object are coalesced and only its latest state
+// is searched when timer of object fires.
+func (a *Application) searchWatched(ctx context.Context, writer ResultWriter, objects <-chan KubernetesObject) error {
+	pending := make(map[string]KubernetesObject)
+	timers := make(map[string]*time.Timer)
+	ready := make(chan string)
+
+	defer func() {
+		for _, timer := range timers {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case obj := <-objects:
+			if a.WatchDebounce == 0 {
+				if err := a.searchChanged(ctx, writer, obj); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			key := obj.Kind + "/" + obj.Namespace + "/" + obj.Name
+			pending[key] = obj
+
+			if _, ok := timers[key]; !ok {
+				timers[key] = time.AfterFunc(a.WatchDebounce, func() {
+					select {
+					case <-ctx.Done():
+					case ready <- key:
+					}
+				})
+			}
+		case key := <-ready:
+			obj := pending[key]
+
+			delete(pending, key)
+			delete(timers, key)
+
+			if err := a.searchChanged(ctx, writer, obj); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *Application) searchChanged(ctx context.Context, writer ResultWriter, obj KubernetesObject) error {
+	a.searchedObjects++
+
+	matches, err := a.searchObject(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		if err := writer.Write(match); err != nil {
+			return err
+		}
+	}
+
+	a.Matches = append(a.Matches, matches...)
+
+	return writer.Flush()
+}
+
+// watchKind sends added and modified objects of kind to objects,
+// watch closed by server is started again from last seen resource version.
+func (a *Application) watchKind(ctx context.Context, kind watchedKind, namespace string, objects chan<- KubernetesObject) error {
+	opts := a.listOptions()
+	opts.AllowWatchBookmarks = true
+
+	if a.latestResourceVersion > 0 {
+		opts.ResourceVersion = strconv.FormatUint(a.latestResourceVersion, 10)
+	}
+
+	for ctx.Err() == nil {
+		watcher, err := a.dynamicClient.Resource(kind.gvr).Namespace(namespace).Watch(ctx, opts)
+		if err == nil {
+			err = a.watchEvents(ctx, watcher, kind, &opts.ResourceVersion, objects)
+
+			watcher.Stop()
+		}
+
+		switch {
+		case err == nil || ctx.Err() != nil:
+			continue
+		case apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+			// changes between expired and current version are not searched
+			slog.Warn(kind.typeOf+" watch expired, watching from current version", "namespace", namespace, "error", err)
+
+			opts.ResourceVersion, err = a.currentResourceVersion(ctx, kind, namespace)
+			if err != nil {
+				return err
+			}
+		case apierrors.IsForbidden(err) && !a.Strict:
+			slog.Warn(kind.typeOf+" watch is forbidden, skipping", "namespace", namespace, "error", err)
+
+			return nil
+		case isTransient(err):
+			slog.Warn(kind.typeOf+" watch failed, retrying", "namespace", namespace, "error", err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(a.retryBackoff().Duration):
+			}
+		default:
+			return errors.Wrap(err, "error in watch "+kind.typeOf)
+		}
+	}
+
+	return nil
+}
+
+// currentResourceVersion lists kind to get resource version to start watch from.
+func (a *Application) currentResourceVersion(ctx context.Context, kind watchedKind, namespace string) (string, error) {
+	opts := a.listOptions()
+	opts.Limit = 1
+
+	list, err := a.dynamicClient.Resource(kind.gvr).Namespace(namespace).List(ctx, opts)
+	if err != nil {
+		return "", errors.Wrap(err, "error in List "+kind.typeOf)
+	}
+
+	return list.GetResourceVersion(), nil
+}
+
+// watchEvents sends objects of watch events until watch is closed,
+// error event is returned as error.
+func (a *Application) watchEvents(ctx context.Context, watcher watch.Interface, kind watchedKind, resourceVersion *string, objects chan<- KubernetesObject) error {
+	for {
+		var event watch.Event
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case received, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+
+			event = received
+		}
+
+		switch event.Type {
+		case watch.Error:
+			return apierrors.FromObject(event.Object)
+		case watch.Added, watch.Modified, watch.Bookmark:
+		default:
+			continue
+		}
+
+		item, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		*resourceVersion = item.GetResourceVersion()
+
+		if event.Type == watch.Bookmark {
+			continue
+		}
+
+		obj, ok, err := a.kubernetesObject(kind.typeOf, typedObject(item))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case objects <- obj:
+		}
+	}
+}
+
+// typedObject converts built-in kinds to their types, so fields
+// are extracted like from listed objects.
+func typedObject(item *unstructured.Unstructured) runtime.Object {
+	obj, err := scheme.Scheme.New(item.GroupVersionKind())
+	if err != nil {
+		return item
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredContent(), obj); err != nil {
+		return item
+	}
+
+	return obj
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSearchWatchedDebounce(t *testing.T) {
+	a := newTestApplication("postgres")
+	a.Watch = true
+	a.WatchDebounce = 50 * time.Millisecond
+	a.dynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	initApplication(t, a)
+
+	objects := make(chan KubernetesObject)
+	writer := &recordWriter{}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+
+	go func() {
+		done <- a.searchWatched(ctx, writer, objects)
+	}()
+
+	for _, url := range []string{"postgres://one", "postgres://two", "postgres://three"} {
+		obj, ok, err := a.kubernetesObject("ConfigMaps", testConfigMap("prod", "cfg", map[string]string{"url": url}))
+		if err != nil || !ok {
+			t.Fatalf("kubernetesObject: %v %v", ok, err)
+		}
+
+		objects <- obj
+	}
+
+	time.Sleep(4 * a.WatchDebounce)
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.matches) != 1 {
+		t.Fatalf("expected 1 match for coalesced changes, got %d", len(writer.matches))
+	}
+
+	if text := writer.matches[0].Text; !strings.Contains(text, "three") {
+		t.Errorf("expected latest object to be searched, got %q", text)
+	}
+}
+
+// cancelWriter records match and cancels watch.
+type cancelWriter struct {
+	recordWriter
+
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(match Match) error {
+	w.cancel()
+
+	return w.recordWriter.Write(match)
+}
+
+func testUnstructured(t *testing.T, obj runtime.Object, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	t.Helper()
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &unstructured.Unstructured{Object: content}
+	item.SetGroupVersionKind(gvk)
+
+	return item
+}
+
+// newWatchApplication returns application watching configmaps in prod namespace,
+// every watch request is served by next watcher.
+func newWatchApplication(t *testing.T, watchers ...watch.Interface) (*Application, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+
+	a := newTestApplication("postgres")
+	a.Watch = true
+	a.WhereToSearch = "configmaps"
+	a.Namespace = "prod"
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme.Scheme, map[schema.GroupVersionResource]string{
+		watchResources["ConfigMaps"]: "ConfigMapList",
+	})
+
+	dynamicClient.PrependWatchReactor("configmaps", func(k8stesting.Action) (bool, watch.Interface, error) {
+		if len(watchers) == 0 {
+			return true, watch.NewFake(), nil
+		}
+
+		watcher := watchers[0]
+		watchers = watchers[1:]
+
+		return true, watcher, nil
+	})
+
+	a.dynamicClient = dynamicClient
+
+	initApplication(t, a)
+
+	return a, dynamicClient
+}
+
+func addedConfigMap(t *testing.T) *watch.FakeWatcher {
+	t.Helper()
+
+	watcher := watch.NewFakeWithChanSize(1, false)
+	watcher.Add(testUnstructured(t,
+		testConfigMap("prod", "cfg", map[string]string{"url": "postgres://db"}),
+		corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+	))
+
+	return watcher
+}
+
+func runWatch(t *testing.T, a *Application) []Match {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	writer := &cancelWriter{cancel: cancel}
+
+	if err := a.watch(ctx, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	return writer.matches
+}
+
+func TestWatchAddedObject(t *testing.T) {
+	a, _ := newWatchApplication(t, addedConfigMap(t))
+
+	if got := matchedObjects(runWatch(t, a)); !slices.Equal(got, []string{"ConfigMaps/prod/cfg"}) {
+		t.Errorf("expected match of added configmap, got %v", got)
+	}
+}
+
+func TestWatchExpired(t *testing.T) {
+	expired := watch.NewFakeWithChanSize(1, false)
+	expired.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+
+	a, dynamicClient := newWatchApplication(t, expired, addedConfigMap(t))
+
+	if got := matchedObjects(runWatch(t, a)); !slices.Equal(got, []string{"ConfigMaps/prod/cfg"}) {
+		t.Errorf("expected watch to continue after expired version, got %v", got)
+	}
+
+	listed := slices.ContainsFunc(dynamicClient.Actions(), func(action k8stesting.Action) bool {
+		return action.GetVerb() == "list"
+	})
+	if !listed {
+		t.Error("expected current resource version to be listed")
+	}
+}
+
+func TestWatchForbidden(t *testing.T) {
+	a, dynamicClient := newWatchApplication(t)
+
+	dynamicClient.PrependWatchReactor("configmaps", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", nil)
+	})
+
+	kind := watchedKind{typeOf: "ConfigMaps", gvr: watchResources["ConfigMaps"], namespaced: true}
+
+	if err := a.watchKind(t.Context(), kind, "prod", make(chan KubernetesObject)); err != nil {
+		t.Errorf("expected forbidden watch to be skipped, got %v", err)
+	}
+}
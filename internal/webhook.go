@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+	webhookBackoff  = time.Second
+)
+
+type webhookPayload struct {
+	Cluster string  `json:"cluster"`
+	Pattern string  `json:"pattern"`
+	Objects int     `json:"objects"`
+	Count   int     `json:"count"`
+	Matches []Match `json:"matches"`
+}
+
+func (a *Application) sendWebhook(ctx context.Context) error {
+	body, err := json.Marshal(webhookPayload{
+		// without -cluster-label cluster is identified by its host
+		Cluster: cmp.Or(a.cluster, a.clusterHost),
+		Pattern: strings.Join(a.WhatToSearch, "|"),
+		Objects: a.searchedObjects,
+		Count:   len(a.Matches),
+		Matches: a.Matches,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error in json.Marshal")
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = a.postWebhook(ctx, body)
+		if err == nil || attempt == webhookAttempts {
+			break
+		}
+
+		slog.Warn("error sending webhook, retrying", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(webhookBackoff * time.Duration(attempt)):
+		}
+	}
+
+	return err
+}
+
+func (a *Application) postWebhook(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "error in http.NewRequest")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "error in http.Do")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return nil
+}
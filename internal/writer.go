@@ -0,0 +1,727 @@
+package internal
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ResultWriter writes matches in some format, Flush must be called
+// after last match.
+type ResultWriter interface {
+	Write(match Match) error
+	Flush() error
+}
+
+// TextWriter logs every match with slog, kind is qualified with api version
+// unless ShortKind is set.
+type TextWriter struct {
+	Logger    *slog.Logger
+	ShortKind bool
+}
+
+func NewTextWriter(logger *slog.Logger, shortKind bool) *TextWriter {
+	return &TextWriter{
+		Logger:    logger,
+		ShortKind: shortKind,
+	}
+}
+
+func (t *TextWriter) Write(match Match) error {
+	kind := match.Kind
+	if !t.ShortKind && match.GVK != "" {
+		kind = match.GVK
+	}
+
+	args := []any{
+		"kind", kind,
+		"name", match.Name,
+		"namespace", match.Namespace,
+	}
+
+	if match.Cluster != "" {
+		args = append(args, "cluster", match.Cluster)
+	}
+
+	if match.Node != "" {
+		args = append(args, "node", match.Node)
+	}
+
+	if match.Path != "" {
+		args = append(args, "path", match.Path)
+	}
+
+	if match.Pattern != "" {
+		args = append(args, "pattern", match.Pattern)
+	}
+
+	if match.MissingOwner != "" {
+		args = append(args, "missingOwner", match.MissingOwner)
+	}
+
+	if match.DeletionTime != "" {
+		args = append(args, "deletionTimestamp", match.DeletionTime)
+	}
+
+	if match.MoreMatches > 0 {
+		args = append(args, "more", fmt.Sprintf("...%d more matches", match.MoreMatches))
+	}
+
+	t.Logger.Info(match.Text, args...)
+
+	return nil
+}
+
+func (t *TextWriter) Flush() error {
+	return nil
+}
+
+// AppGroupWriter logs matching objects grouped by cluster, application and kind.
+type AppGroupWriter struct {
+	Logger *slog.Logger
+	apps   map[appGroup]map[string][]string
+}
+
+type appGroup struct {
+	cluster string
+	app     string
+}
+
+func NewAppGroupWriter(logger *slog.Logger) *AppGroupWriter {
+	return &AppGroupWriter{
+		Logger: logger,
+		apps:   make(map[appGroup]map[string][]string),
+	}
+}
+
+func (g *AppGroupWriter) Write(match Match) error {
+	group := appGroup{cluster: match.Cluster, app: match.App}
+
+	kinds, ok := g.apps[group]
+	if !ok {
+		kinds = make(map[string][]string)
+		g.apps[group] = kinds
+	}
+
+	object := match.Namespace + "/" + match.Name
+
+	if !slices.Contains(kinds[match.Kind], object) {
+		kinds[match.Kind] = append(kinds[match.Kind], object)
+	}
+
+	return nil
+}
+
+func (g *AppGroupWriter) Flush() error {
+	groups := slices.SortedFunc(maps.Keys(g.apps), func(x, y appGroup) int {
+		return cmp.Or(strings.Compare(x.cluster, y.cluster), strings.Compare(x.app, y.app))
+	})
+
+	for _, group := range groups {
+		kinds := g.apps[group]
+
+		for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+			args := []any{
+				"kind", kind,
+				"count", len(kinds[kind]),
+				"objects", strings.Join(kinds[kind], " "),
+			}
+
+			if group.cluster != "" {
+				args = append(args, "cluster", group.cluster)
+			}
+
+			g.Logger.Info(group.app, args...)
+		}
+	}
+
+	return nil
+}
+
+// JSONWriter writes all matches as one JSON array on Flush.
+type JSONWriter struct {
+	w       io.Writer
+	pretty  bool
+	matches []Match
+}
+
+func NewJSONWriter(w io.Writer, pretty bool) *JSONWriter {
+	return &JSONWriter{
+		w:       w,
+		pretty:  pretty,
+		matches: make([]Match, 0),
+	}
+}
+
+func (j *JSONWriter) Write(match Match) error {
+	j.matches = append(j.matches, match)
+
+	return nil
+}
+
+func (j *JSONWriter) Flush() error {
+	encoder := json.NewEncoder(j.w)
+
+	if j.pretty {
+		encoder.SetIndent("", "  ")
+	}
+
+	if err := encoder.Encode(j.matches); err != nil {
+		return errors.Wrap(err, "error in json.Encode")
+	}
+
+	return nil
+}
+
+// NDJSONWriter writes every match as JSON on its own line.
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *NDJSONWriter) Write(match Match) error {
+	if err := n.encoder.Encode(match); err != nil {
+		return errors.Wrap(err, "error in json.Encode")
+	}
+
+	return nil
+}
+
+func (n *NDJSONWriter) Flush() error {
+	return nil
+}
+
+// YAMLWriter writes every match as YAML document.
+type YAMLWriter struct {
+	w io.Writer
+}
+
+func NewYAMLWriter(w io.Writer) *YAMLWriter {
+	return &YAMLWriter{w: w}
+}
+
+func (y *YAMLWriter) Write(match Match) error {
+	body, err := yaml.Marshal(match)
+	if err != nil {
+		return errors.Wrap(err, "error in yaml.Marshal")
+	}
+
+	if _, err := io.WriteString(y.w, "---\n"); err != nil {
+		return errors.Wrap(err, "error in io.WriteString")
+	}
+
+	if _, err := y.w.Write(body); err != nil {
+		return errors.Wrap(err, "error in Write")
+	}
+
+	return nil
+}
+
+func (y *YAMLWriter) Flush() error {
+	return nil
+}
+
+// ObjectsWriter writes every matching object once as full YAML document,
+// cluster of labeled matches is written in comment of document separator.
+type ObjectsWriter struct {
+	w      io.Writer
+	redact func(string) string
+	seen   map[string]bool
+}
+
+func NewObjectsWriter(w io.Writer, redact func(string) string) *ObjectsWriter {
+	return &ObjectsWriter{
+		w:      w,
+		redact: redact,
+		seen:   make(map[string]bool),
+	}
+}
+
+func (o *ObjectsWriter) Write(match Match) error {
+	key := match.Cluster + "/" + match.Kind + "/" + match.Namespace + "/" + match.Name
+	if o.seen[key] || match.object == nil {
+		return nil
+	}
+
+	o.seen[key] = true
+
+	object := match.object
+
+	// typed objects from lists have no apiVersion and kind
+	if object.GetObjectKind().GroupVersionKind().Empty() {
+		object = object.DeepCopyObject()
+		object.GetObjectKind().SetGroupVersionKind(match.gvk)
+	}
+
+	body, err := yaml.Marshal(object)
+	if err != nil {
+		return errors.Wrap(err, "error in yaml.Marshal")
+	}
+
+	separator := "---\n"
+	if match.Cluster != "" {
+		separator = "--- # cluster: " + match.Cluster + "\n"
+	}
+
+	if _, err := io.WriteString(o.w, separator+o.redact(string(body))); err != nil {
+		return errors.Wrap(err, "error in io.WriteString")
+	}
+
+	return nil
+}
+
+func (o *ObjectsWriter) Flush() error {
+	return nil
+}
+
+var csvHeader = []string{"kind", "namespace", "name", "path", "text", "match", "offset", "gvk"}
+
+// CSVWriter writes every match as CSV row after header, first column
+// is cluster when matches are labeled with -cluster-label.
+type CSVWriter struct {
+	w       *csv.Writer
+	header  bool
+	cluster bool
+}
+
+func NewCSVWriter(w io.Writer, delimiter rune) *CSVWriter {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	return &CSVWriter{w: writer}
+}
+
+func (c *CSVWriter) Write(match Match) error {
+	if !c.header {
+		c.cluster = match.Cluster != ""
+
+		header := csvHeader
+		if c.cluster {
+			header = append([]string{"cluster"}, csvHeader...)
+		}
+
+		if err := c.w.Write(header); err != nil {
+			return errors.Wrap(err, "error in csv.Write")
+		}
+
+		c.header = true
+	}
+
+	row := []string{
+		match.Kind,
+		match.Namespace,
+		match.Name,
+		match.Path,
+		match.Text,
+		match.Match,
+		strconv.Itoa(match.Offset),
+		match.GVK,
+	}
+
+	if c.cluster {
+		row = append([]string{match.Cluster}, row...)
+	}
+
+	if err := c.w.Write(row); err != nil {
+		return errors.Wrap(err, "error in csv.Write")
+	}
+
+	return nil
+}
+
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+
+	if err := c.w.Error(); err != nil {
+		return errors.Wrap(err, "error in csv.Flush")
+	}
+
+	return nil
+}
+
+// TemplateWriter executes template for every match.
+type TemplateWriter struct {
+	w        io.Writer
+	template *template.Template
+	newline  bool
+}
+
+// NewTemplateWriter creates writer, with newline it ends every match with new line.
+func NewTemplateWriter(w io.Writer, template *template.Template, newline bool) *TemplateWriter {
+	return &TemplateWriter{
+		w:        w,
+		template: template,
+		newline:  newline,
+	}
+}
+
+func (t *TemplateWriter) Write(match Match) error {
+	if err := t.template.Execute(t.w, match); err != nil {
+		return errors.Wrap(err, "error in template.Execute")
+	}
+
+	if !t.newline {
+		return nil
+	}
+
+	if _, err := io.WriteString(t.w, "\n"); err != nil {
+		return errors.Wrap(err, "error in io.WriteString")
+	}
+
+	return nil
+}
+
+func (t *TemplateWriter) Flush() error {
+	return nil
+}
+
+const compactWidth = 80
+
+// CompactWriter writes identities of matching objects grouped by namespace
+// on wrapped lines, like "ns1: deploy/a deploy/b cm/c", lines are prefixed
+// with cluster when matches are labeled with -cluster-label.
+type CompactWriter struct {
+	w      io.Writer
+	groups map[compactGroup][]string
+}
+
+type compactGroup struct {
+	cluster   string
+	namespace string
+}
+
+func NewCompactWriter(w io.Writer) *CompactWriter {
+	return &CompactWriter{
+		w:      w,
+		groups: make(map[compactGroup][]string),
+	}
+}
+
+func (c *CompactWriter) Write(match Match) error {
+	identity := shortKind(match.Kind) + "/" + match.Name
+	group := compactGroup{cluster: match.Cluster, namespace: match.Namespace}
+
+	if !slices.Contains(c.groups[group], identity) {
+		c.groups[group] = append(c.groups[group], identity)
+	}
+
+	return nil
+}
+
+func (c *CompactWriter) Flush() error {
+	groups := slices.SortedFunc(maps.Keys(c.groups), func(x, y compactGroup) int {
+		return cmp.Or(strings.Compare(x.cluster, y.cluster), strings.Compare(x.namespace, y.namespace))
+	})
+
+	for _, group := range groups {
+		prefix := group.namespace + ":"
+		if group.namespace == "" {
+			prefix = "(cluster):"
+		}
+
+		if group.cluster != "" {
+			prefix = group.cluster + " " + prefix
+		}
+
+		line := prefix
+
+		for _, identity := range c.groups[group] {
+			if len(line)+1+len(identity) > compactWidth && line != prefix {
+				if _, err := io.WriteString(c.w, line+"\n"); err != nil {
+					return errors.Wrap(err, "error in io.WriteString")
+				}
+
+				line = strings.Repeat(" ", len(prefix))
+			}
+
+			line += " " + identity
+		}
+
+		if _, err := io.WriteString(c.w, line+"\n"); err != nil {
+			return errors.Wrap(err, "error in io.WriteString")
+		}
+	}
+
+	return nil
+}
+
+// NameWriter writes every matching object once as "kind/namespace/name"
+// like kubectl get -o name, cluster scoped objects have no namespace.
+// Names are prefixed with cluster and space when matches are labeled.
+type NameWriter struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+func NewNameWriter(w io.Writer) *NameWriter {
+	return &NameWriter{
+		w:    w,
+		seen: make(map[string]bool),
+	}
+}
+
+func (n *NameWriter) Write(match Match) error {
+	name := strings.ToLower(match.Kind) + "/" + match.Name
+	if match.Namespace != "" {
+		name = strings.ToLower(match.Kind) + "/" + match.Namespace + "/" + match.Name
+	}
+
+	if match.Cluster != "" {
+		name = match.Cluster + " " + name
+	}
+
+	if n.seen[name] {
+		return nil
+	}
+
+	n.seen[name] = true
+
+	if _, err := io.WriteString(n.w, name+"\n"); err != nil {
+		return errors.Wrap(err, "error in io.WriteString")
+	}
+
+	return nil
+}
+
+func (n *NameWriter) Flush() error {
+	return nil
+}
+
+const histogramWidth = 40
+
+var histogramBuckets = []struct {
+	Label string
+	Max   int
+}{
+	{"1", 1},
+	{"2-5", 5},
+	{"6-20", 20},
+	{"20+", math.MaxInt},
+}
+
+// HistogramWriter writes distribution of objects by number of matches in them,
+// labeled matches get histogram of every cluster after its name.
+type HistogramWriter struct {
+	w        io.Writer
+	clusters map[string]map[string]int
+}
+
+func NewHistogramWriter(w io.Writer) *HistogramWriter {
+	return &HistogramWriter{
+		w:        w,
+		clusters: make(map[string]map[string]int),
+	}
+}
+
+func (h *HistogramWriter) Write(match Match) error {
+	if h.clusters[match.Cluster] == nil {
+		h.clusters[match.Cluster] = make(map[string]int)
+	}
+
+	h.clusters[match.Cluster][match.Kind+"/"+match.Namespace+"/"+match.Name]++
+
+	return nil
+}
+
+func (h *HistogramWriter) Flush() error {
+	if len(h.clusters) == 0 {
+		return h.writeHistogram(nil)
+	}
+
+	for _, cluster := range slices.Sorted(maps.Keys(h.clusters)) {
+		if cluster != "" {
+			if _, err := fmt.Fprintf(h.w, "cluster: %s\n", cluster); err != nil {
+				return errors.Wrap(err, "error in fmt.Fprintf")
+			}
+		}
+
+		if err := h.writeHistogram(h.clusters[cluster]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *HistogramWriter) writeHistogram(objects map[string]int) error {
+	counts := make([]int, len(histogramBuckets))
+
+	for _, matches := range objects {
+		for i, bucket := range histogramBuckets {
+			if matches <= bucket.Max {
+				counts[i]++
+
+				break
+			}
+		}
+	}
+
+	maxCount := slices.Max(counts)
+
+	for i, bucket := range histogramBuckets {
+		bar := 0
+		if maxCount > 0 {
+			bar = counts[i] * histogramWidth / maxCount
+		}
+
+		if counts[i] > 0 && bar == 0 {
+			bar = 1
+		}
+
+		_, err := fmt.Fprintf(h.w, "%-5s | %s %d\n", bucket.Label, strings.Repeat("█", bar), counts[i])
+		if err != nil {
+			return errors.Wrap(err, "error in fmt.Fprintf")
+		}
+	}
+
+	return nil
+}
+
+// SummaryWriter writes only single machine-parseable line with totals.
+type SummaryWriter struct {
+	w        io.Writer
+	started  time.Time
+	matches  int
+	objects  map[string]bool
+	kinds    map[string]bool
+	clusters map[string]bool
+}
+
+func NewSummaryWriter(w io.Writer, started time.Time) *SummaryWriter {
+	return &SummaryWriter{
+		w:        w,
+		started:  started,
+		objects:  make(map[string]bool),
+		kinds:    make(map[string]bool),
+		clusters: make(map[string]bool),
+	}
+}
+
+func (s *SummaryWriter) Write(match Match) error {
+	s.matches++
+	s.objects[match.Cluster+"/"+match.Kind+"/"+match.Namespace+"/"+match.Name] = true
+	s.kinds[match.Kind] = true
+
+	if match.Cluster != "" {
+		s.clusters[match.Cluster] = true
+	}
+
+	return nil
+}
+
+func (s *SummaryWriter) Flush() error {
+	// like grep, exit code is 1 when nothing found
+	exitCode := 0
+	if s.matches == 0 {
+		exitCode = 1
+	}
+
+	// line starts with clusters of labeled matches
+	prefix := ""
+	if len(s.clusters) > 0 {
+		prefix = "cluster=" + strings.Join(slices.Sorted(maps.Keys(s.clusters)), ",") + " "
+	}
+
+	_, err := fmt.Fprintf(s.w, "%smatches=%d objects=%d kinds=%d duration=%s exit=%d\n",
+		prefix,
+		s.matches,
+		len(s.objects),
+		len(s.kinds),
+		time.Since(s.started).Round(100*time.Millisecond),
+		exitCode,
+	)
+	if err != nil {
+		return errors.Wrap(err, "error in fmt.Fprintf")
+	}
+
+	return nil
+}
+
+// CountWriter writes number of matching objects and matches of every kind
+// instead of matches, lines of labeled matches start with cluster.
+type CountWriter struct {
+	w       io.Writer
+	matches map[countGroup]int
+	objects map[countGroup]map[string]bool
+}
+
+type countGroup struct {
+	cluster string
+	kind    string
+}
+
+func NewCountWriter(w io.Writer) *CountWriter {
+	return &CountWriter{
+		w:       w,
+		matches: make(map[countGroup]int),
+		objects: make(map[countGroup]map[string]bool),
+	}
+}
+
+func (c *CountWriter) Write(match Match) error {
+	group := countGroup{cluster: match.Cluster, kind: match.Kind}
+
+	if c.objects[group] == nil {
+		c.objects[group] = make(map[string]bool)
+	}
+
+	c.matches[group]++
+	c.objects[group][match.Namespace+"/"+match.Name] = true
+
+	return nil
+}
+
+func (c *CountWriter) Flush() error {
+	objects, matches := 0, 0
+
+	groups := slices.SortedFunc(maps.Keys(c.matches), func(x, y countGroup) int {
+		return cmp.Or(strings.Compare(x.cluster, y.cluster), strings.Compare(x.kind, y.kind))
+	})
+
+	for _, group := range groups {
+		objects += len(c.objects[group])
+		matches += c.matches[group]
+
+		prefix := ""
+		if group.cluster != "" {
+			prefix = "cluster=" + group.cluster + " "
+		}
+
+		if _, err := fmt.Fprintf(c.w, "%skind=%s objects=%d matches=%d\n", prefix, group.kind, len(c.objects[group]), c.matches[group]); err != nil {
+			return errors.Wrap(err, "error in fmt.Fprintf")
+		}
+	}
+
+	if _, err := fmt.Fprintf(c.w, "total objects=%d matches=%d\n", objects, matches); err != nil {
+		return errors.Wrap(err, "error in fmt.Fprintf")
+	}
+
+	return nil
+}
+
+// DiscardWriter drops all matches, only exit code reports result.
+type DiscardWriter struct{}
+
+func (DiscardWriter) Write(Match) error {
+	return nil
+}
+
+func (DiscardWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func writeAll(t *testing.T, writer ResultWriter, matches ...Match) {
+	t.Helper()
+
+	for _, match := range matches {
+		if err := writer.Write(match); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterLabelOutputs(t *testing.T) {
+	match := Match{Cluster: "prod", Kind: "Pods", Namespace: "web", Name: "nginx", Text: "image: nginx", Match: "nginx"}
+	pod := testPod("web", "nginx", "nginx")
+
+	tests := []struct {
+		name   string
+		writer func(*bytes.Buffer) ResultWriter
+		want   string
+	}{
+		{"csv", func(b *bytes.Buffer) ResultWriter { return NewCSVWriter(b, ',') }, "cluster,kind,namespace,name,path,text,match,offset,gvk\nprod,Pods,web,nginx,,image: nginx,nginx,0,\n"},
+		{"name", func(b *bytes.Buffer) ResultWriter { return NewNameWriter(b) }, "prod pods/web/nginx\n"},
+		{"compact", func(b *bytes.Buffer) ResultWriter { return NewCompactWriter(b) }, "prod web: po/nginx\n"},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+
+		writeAll(t, tt.writer(&b), match)
+
+		if b.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, b.String())
+		}
+	}
+
+	var b bytes.Buffer
+
+	objectMatch := match
+	objectMatch.object = pod
+
+	writeAll(t, NewObjectsWriter(&b, func(s string) string { return s }), objectMatch)
+
+	if !strings.HasPrefix(b.String(), "--- # cluster: prod\n") {
+		t.Errorf("objects: expected cluster in document separator, got %q", b.String())
+	}
+}
+
+func TestOutputsWithoutCluster(t *testing.T) {
+	match := Match{Kind: "Pods", Namespace: "web", Name: "nginx", Text: "image: nginx", Match: "nginx"}
+
+	var csvOut, nameOut, compactOut bytes.Buffer
+
+	writeAll(t, NewCSVWriter(&csvOut, ','), match)
+	writeAll(t, NewNameWriter(&nameOut), match)
+	writeAll(t, NewCompactWriter(&compactOut), match)
+
+	if !strings.HasPrefix(csvOut.String(), "kind,") {
+		t.Errorf("csv: expected no cluster column, got %q", csvOut.String())
+	}
+
+	if nameOut.String() != "pods/web/nginx\n" {
+		t.Errorf("name: got %q", nameOut.String())
+	}
+
+	if compactOut.String() != "web: po/nginx\n" {
+		t.Errorf("compact: got %q", compactOut.String())
+	}
+}
+
+func TestCountWriter(t *testing.T) {
+	a := newTestApplication("nginx",
+		testPod("prod", "web", "nginx"),
+		testPod("dev", "web", "nginx"),
+		testConfigMap("prod", "cfg", map[string]string{"image": "nginx", "proxy": "nginx"}),
+		testConfigMap("prod", "other", map[string]string{"image": "redis"}),
+	)
+	a.Count = true
+
+	var b bytes.Buffer
+
+	a.ResultWriter = NewCountWriter(&b)
+	initApplication(t, a)
+
+	if err := a.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "kind=ConfigMaps objects=1 matches=2\nkind=Pods objects=2 matches=2\ntotal objects=3 matches=4\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestNameWriterDeduplicates(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "prod",
+		Annotations: map[string]string{"registry": "registry.example.com"},
+	}}
+
+	a := newTestApplication("registry.example.com",
+		namespace,
+		testConfigMap("prod", "images", map[string]string{
+			"api": "registry.example.com/api:1.0",
+			"web": "registry.example.com/web:1.0",
+		}),
+		testPod("prod", "api", "registry.example.com/api:1.0"),
+	)
+	a.Sort = true
+
+	matches := findMatches(t, a)
+	if len(matches) < 4 {
+		t.Fatalf("expected several matches in same objects, got %d", len(matches))
+	}
+
+	var buf bytes.Buffer
+
+	writeAll(t, NewNameWriter(&buf), matches...)
+
+	want := "configmaps/prod/images\nnamespaces/prod\npods/prod/api\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestClusterLabelAggregateOutputs(t *testing.T) {
+	matches := []Match{
+		{Cluster: "prod", Kind: "Pods", Namespace: "web", Name: "nginx", App: "web"},
+		{Cluster: "dev", Kind: "Pods", Namespace: "web", Name: "nginx", App: "web"},
+		{Cluster: "dev", Kind: "ConfigMaps", Namespace: "web", Name: "nginx", App: "web"},
+	}
+
+	var count, histogram, summary, group bytes.Buffer
+
+	writeAll(t, NewCountWriter(&count), matches...)
+	writeAll(t, NewHistogramWriter(&histogram), matches...)
+	writeAll(t, NewSummaryWriter(&summary, time.Now()), matches...)
+	writeAll(t, NewAppGroupWriter(slog.New(slog.NewTextHandler(&group, &slog.HandlerOptions{ReplaceAttr: withoutTime}))), matches...)
+
+	wantCount := "cluster=dev kind=ConfigMaps objects=1 matches=1\n" +
+		"cluster=dev kind=Pods objects=1 matches=1\n" +
+		"cluster=prod kind=Pods objects=1 matches=1\n" +
+		"total objects=3 matches=3\n"
+	if count.String() != wantCount {
+		t.Errorf("count: expected %q, got %q", wantCount, count.String())
+	}
+
+	if got := histogram.String(); !strings.HasPrefix(got, "cluster: dev\n1     | ") || !strings.Contains(got, "cluster: prod\n1     | ") {
+		t.Errorf("histogram: expected histogram of every cluster, got %q", got)
+	}
+
+	if got := summary.String(); !strings.HasPrefix(got, "cluster=dev,prod matches=3 objects=3 kinds=2 ") {
+		t.Errorf("summary: expected clusters in summary, got %q", got)
+	}
+
+	wantGroup := "level=INFO msg=web kind=ConfigMaps count=1 objects=web/nginx cluster=dev\n" +
+		"level=INFO msg=web kind=Pods count=1 objects=web/nginx cluster=dev\n" +
+		"level=INFO msg=web kind=Pods count=1 objects=web/nginx cluster=prod\n"
+	if group.String() != wantGroup {
+		t.Errorf("group by app: expected %q, got %q", wantGroup, group.String())
+	}
+}
+
+func TestWebhookCluster(t *testing.T) {
+	tests := []struct {
+		cluster string
+		want    string
+	}{
+		{"prod", "prod"},
+		// without label host identifies cluster
+		{"", "https://prod.example.com"},
+	}
+
+	for _, tt := range tests {
+		var payload webhookPayload
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Error(err)
+			}
+		}))
+
+		a := NewApplication()
+		a.Webhook = server.URL
+		a.cluster = tt.cluster
+		a.clusterHost = "https://prod.example.com"
+
+		err := a.sendWebhook(t.Context())
+
+		server.Close()
+
+		if err != nil {
+			t.Fatalf("sendWebhook: %v", err)
+		}
+
+		if payload.Cluster != tt.want {
+			t.Errorf("expected cluster %q in webhook, got %q", tt.want, payload.Cluster)
+		}
+	}
+}